@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/service"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runCommand executes a command-line subcommand instead of starting the server.
+func runCommand(log *slog.Logger, db *storage, args []string) error {
+	switch args[0] {
+	case "import-rates":
+		return importRates(log, db, args[1:])
+	case "migrate":
+		return migrate(log, db, args[1:])
+	case "seed":
+		return seed(log, db)
+	case "create-api-key":
+		return createAPIKey(log, db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// importRates loads exchange rates from a local file: import-rates -file rates.csv
+func importRates(log *slog.Logger, db *storage, args []string) error {
+	flags := flag.NewFlagSet("import-rates", flag.ContinueOnError)
+	path := flags.String("file", "", "path to a .csv or .json file with exchange rates")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		return errors.New("import-rates: -file is required")
+	}
+
+	exchangeRateService := service.NewExchangeRateService(log, db.exchangeRates)
+
+	_, err := exchangeRateService.ImportFile(context.Background(), *path)
+
+	return err
+}
+
+// migrate manages schema migrations: migrate up | down [N] | status | goto N | force N
+func migrate(log *slog.Logger, db *storage, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate: expected up, down, status, goto or force")
+	}
+
+	m, err := db.migrator()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := m.Up()
+		if err != nil {
+			return err
+		}
+		log.Info("migrations applied", "count", count, "version", m.Latest())
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New("migrate down: number of steps must be a positive integer")
+			}
+		}
+
+		count, err := m.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Info("migrations rolled back", "count", count)
+	case "goto", "force":
+		if len(args) < 2 {
+			return fmt.Errorf("migrate %s: version is required", args[0])
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("migrate %s: version must be a non-negative integer", args[0])
+		}
+
+		if args[0] == "force" {
+			err = m.Force(version)
+			if err != nil {
+				return err
+			}
+			log.Info("migration version forced", "version", version)
+			return nil
+		}
+
+		count, err := m.Goto(version)
+		if err != nil {
+			return err
+		}
+		log.Info("migrated", "count", count, "version", version)
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("migrate: unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+// seed inserts demo data into an empty database.
+func seed(log *slog.Logger, db *storage) error {
+	m, err := db.migrator()
+	if err != nil {
+		return err
+	}
+
+	count, err := m.Seed(db.seeds)
+	if err != nil {
+		return err
+	}
+
+	log.Info("seed files applied", "count", count)
+
+	return nil
+}
+
+// createAPIKey creates an API key and prints it: create-api-key -name billing -scopes read,reports [-expires 720h]
+func createAPIKey(log *slog.Logger, db *storage, args []string) error {
+	flags := flag.NewFlagSet("create-api-key", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the service client")
+	scopes := flags.String("scopes", "", "comma-separated scopes: read, write, reports, admin")
+	expires := flags.Duration("expires", 0, "lifetime of the key, the key does not expire if omitted")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	key := &models.APIKey{Name: *name}
+
+	if *scopes != "" {
+		key.Scopes = strings.Split(*scopes, ",")
+	}
+
+	if *expires > 0 {
+		expiresAt := time.Now().Add(*expires).UTC()
+		key.ExpiresAt = &expiresAt
+	}
+
+	v := validator.New()
+
+	if models.ValidateAPIKey(v, key); !v.Valid() {
+		return fmt.Errorf("create-api-key: %v", v.Errors)
+	}
+
+	apiKeyService := service.NewAPIKeyService(log, db.apiKeys)
+
+	token, err := apiKeyService.Create(context.Background(), key)
+	if err != nil {
+		return err
+	}
+
+	log.Info("api key created", "id", key.ID, "prefix", key.Prefix)
+	fmt.Println(token)
+
+	return nil
+}
+
+// checkSchema applies pending migrations or refuses to start with an outdated schema,
+// depending on the configuration.
+func checkSchema(log *slog.Logger, cfg config.MigrationsConfig, db *storage) error {
+	m, err := db.migrator()
+	if err != nil {
+		return err
+	}
+
+	if cfg.ApplyOnStart {
+		count, err := m.Up()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			log.Info("migrations applied", "count", count, "version", m.Latest())
+		}
+		return nil
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if cfg.AllowOutdated {
+		log.Warn("database schema is outdated", "pending", len(pending))
+		return nil
+	}
+
+	return fmt.Errorf("database schema is outdated: %d pending migrations, run migrate up", len(pending))
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"eff-subscriptions/internal/app"
 	"eff-subscriptions/internal/config"
-	"eff-subscriptions/internal/repository/postgres"
+	"eff-subscriptions/internal/logging"
+	"eff-subscriptions/internal/repository/memory"
+	"eff-subscriptions/internal/service"
 	"github.com/joho/godotenv"
 	"log/slog"
 	"os"
@@ -18,9 +20,15 @@ const (
 // @title eff-subscriptions
 // @version 1.0
 // @description API server for subscription application.
+// @description Errors are returned as application/problem+json (RFC 9457) to clients which prefer it in the Accept header.
 
 // @host localhost:8180
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT access token or API key with the Bearer prefix, required when authentication is enabled
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -31,14 +39,48 @@ func main() {
 
 	log := setupLogger(cfg.Env)
 
-	pgDB, err := postgres.NewPostgresDB(cfg.PostgresDBConfig)
+	if cfg.Storage == config.StorageMemory {
+		if len(os.Args) > 1 {
+			log.Error("commands require a database storage", "storage", cfg.Storage)
+			os.Exit(1)
+		}
+
+		subscriptionRepository := memory.NewSubscriptionRepository(memory.NewExchangeRateRepository())
+
+		application := app.New(log, cfg, subscriptionRepository, memory.NewIdempotencyKeyRepository(), memory.NewAPIKeyRepository(), memory.NewRateLimitRepository())
+
+		application.MustRun()
+		return
+	}
+
+	db, err := openStorage(cfg)
 	if err != nil {
 		log.Error(err.Error())
 		os.Exit(1)
 	}
-	defer pgDB.Close()
+	defer db.Close()
+
+	if len(os.Args) > 1 {
+		err = runCommand(log, db, os.Args[1:])
+		if err != nil {
+			log.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = checkSchema(log, cfg.MigrationsConfig, db)
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+
+	var rateLimits service.RateLimitProvider = memory.NewRateLimitRepository()
+	if cfg.HTTPConfig.RateLimit.Store == config.RateLimitStorePostgres {
+		rateLimits = db.rateLimits
+	}
 
-	application := app.New(log, cfg, pgDB)
+	application := app.New(log, cfg, db.subscriptions, db.idempotencyKeys, db.apiKeys, rateLimits)
 
 	application.MustRun()
 }
@@ -49,19 +91,19 @@ func setupLogger(env string) *slog.Logger {
 	switch env {
 	case envLocal:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			logging.NewContextHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envDev:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			logging.NewContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	case envProd:
 		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+			logging.NewContextHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})),
 		)
 	default:
 		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			logging.NewContextHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})),
 		)
 	}
 
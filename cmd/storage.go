@@ -0,0 +1,86 @@
+package main
+
+import (
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/migrator"
+	"eff-subscriptions/internal/repository/postgres"
+	"eff-subscriptions/internal/repository/sqlite"
+	"eff-subscriptions/internal/service"
+	"eff-subscriptions/migrations"
+	"io/fs"
+)
+
+// storage a database storage together with its migrations and repositories.
+type storage struct {
+	db              *sql.DB
+	dialect         migrator.Dialect
+	schema          fs.FS
+	seeds           fs.FS
+	subscriptions   service.SubscriptionProvider
+	exchangeRates   service.ExchangeRateProvider
+	idempotencyKeys service.IdempotencyKeyProvider
+	apiKeys         service.APIKeyProvider
+	rateLimits      service.RateLimitProvider
+}
+
+// openStorage connects to the database storage selected by the configuration.
+func openStorage(cfg *config.Config) (*storage, error) {
+	if cfg.Storage == config.StorageSQLite {
+		db, err := sqlite.NewSQLiteDB(cfg.SQLiteDBConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		schema, err := fs.Sub(migrations.SQLite, "sqlite")
+		if err != nil {
+			return nil, err
+		}
+
+		seeds, err := fs.Sub(migrations.SQLiteSeeds, "sqlite/seeds")
+		if err != nil {
+			return nil, err
+		}
+
+		return &storage{
+			db:              db,
+			dialect:         migrator.SQLite,
+			schema:          schema,
+			seeds:           seeds,
+			subscriptions:   sqlite.NewSubscriptionRepository(db, cfg.SQLiteDBConfig.Timeouts),
+			exchangeRates:   sqlite.NewExchangeRateRepository(db, cfg.SQLiteDBConfig.Timeouts),
+			idempotencyKeys: sqlite.NewIdempotencyKeyRepository(db, cfg.SQLiteDBConfig.Timeouts),
+			apiKeys:         sqlite.NewAPIKeyRepository(db, cfg.SQLiteDBConfig.Timeouts),
+		}, nil
+	}
+
+	db, err := postgres.NewPostgresDB(cfg.PostgresDBConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	seeds, err := fs.Sub(migrations.Seeds, "seeds")
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage{
+		db:              db,
+		dialect:         migrator.Postgres,
+		schema:          migrations.Schema,
+		seeds:           seeds,
+		subscriptions:   postgres.NewSubscriptionRepository(db, cfg.PostgresDBConfig.Timeouts),
+		exchangeRates:   postgres.NewExchangeRateRepository(db, cfg.PostgresDBConfig.Timeouts),
+		idempotencyKeys: postgres.NewIdempotencyKeyRepository(db, cfg.PostgresDBConfig.Timeouts),
+		apiKeys:         postgres.NewAPIKeyRepository(db, cfg.PostgresDBConfig.Timeouts),
+		rateLimits:      postgres.NewRateLimitRepository(db, cfg.PostgresDBConfig.Timeouts),
+	}, nil
+}
+
+func (s *storage) migrator() (*migrator.Migrator, error) {
+	return migrator.New(s.db, s.dialect, s.schema)
+}
+
+func (s *storage) Close() error {
+	return s.db.Close()
+}
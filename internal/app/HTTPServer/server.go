@@ -4,24 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 )
 
+// ErrShutdown cause of the cancellation of requests still running when the graceful shutdown times out.
+var ErrShutdown = errors.New("server is shutting down")
+
 type Server struct {
 	httpServer *http.Server
+	cancel     context.CancelCauseFunc
 }
 
 func NewServer(port int, timeout time.Duration, handler http.Handler) *Server {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
 	httpServer := &http.Server{
 		Addr:           fmt.Sprintf(":%d", port),
 		Handler:        handler,
 		MaxHeaderBytes: 1 << 20,
 		ReadTimeout:    timeout,
 		WriteTimeout:   timeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
 	}
 
-	return &Server{httpServer: httpServer}
+	return &Server{httpServer: httpServer, cancel: cancel}
 }
 
 func (s *Server) Addr() string {
@@ -40,6 +50,11 @@ func (s *Server) Run() error {
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown waits for running requests until ctx is done and then cancels the requests
+// which are still running, so that their queries are interrupted.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	s.cancel(ErrShutdown)
+
+	return err
 }
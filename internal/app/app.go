@@ -2,11 +2,12 @@ package app
 
 import (
 	"context"
-	"database/sql"
+	"crypto/rand"
 	"eff-subscriptions/internal/app/HTTPServer"
+	"eff-subscriptions/internal/auth"
 	"eff-subscriptions/internal/config"
 	"eff-subscriptions/internal/delivery/http"
-	"eff-subscriptions/internal/repository/postgres"
+	"eff-subscriptions/internal/domain/models"
 	"eff-subscriptions/internal/service"
 	"log/slog"
 	"os"
@@ -16,28 +17,82 @@ import (
 )
 
 type App struct {
-	log        *slog.Logger
-	cfg        *config.Config
-	hTTPServer *HTTPServer.Server
+	log                 *slog.Logger
+	cfg                 *config.Config
+	hTTPServer          *HTTPServer.Server
+	subscriptionService *service.SubscriptionService
+	idempotencyService  *service.IdempotencyService
+	rateLimitService    *service.RateLimitService
 }
 
-func New(log *slog.Logger, cfg *config.Config, pgDB *sql.DB) *App {
-	subscriptionRepository := postgres.NewSubscriptionRepository(pgDB)
-	subscriptionService := service.NewSubscriptionService(log, subscriptionRepository)
-	handler := http.NewHandler(log, subscriptionService)
+func New(log *slog.Logger, cfg *config.Config, subscriptionProvider service.SubscriptionProvider, idempotencyKeyProvider service.IdempotencyKeyProvider, apiKeyProvider service.APIKeyProvider, rateLimitProvider service.RateLimitProvider) *App {
+	subscriptionService := service.NewSubscriptionService(log, subscriptionProvider)
+	idempotencyService := service.NewIdempotencyService(log, idempotencyKeyProvider, cfg.IdempotencyConfig.TTL, cfg.IdempotencyConfig.LockTimeout)
+	apiKeyService := service.NewAPIKeyService(log, apiKeyProvider)
+
+	cursorSecret := []byte(cfg.PaginationConfig.CursorSecret)
+	if len(cursorSecret) == 0 {
+		log.Warn("cursor secret is not configured, pagination cursors will not survive a restart")
+		cursorSecret = make([]byte, 32)
+		_, _ = rand.Read(cursorSecret)
+	}
+
+	var authenticator *auth.Authenticator
+	if cfg.AuthConfig.Enabled {
+		var err error
+
+		authenticator, err = auth.NewAuthenticator(cfg.AuthConfig)
+		if err != nil {
+			panic("failed to load authentication keys: " + err.Error())
+		}
+
+		if cfg.AuthConfig.HMACSecret == "" && cfg.AuthConfig.RSAPublicKeyFile == "" && cfg.AuthConfig.JWKSFile == "" {
+			log.Warn("no token keys are configured, callers can authenticate only with API keys")
+		}
+	} else {
+		log.Warn("authentication is disabled, callers without an API key can access subscriptions of all users")
+	}
+
+	var rateLimitService *service.RateLimitService
+	if rateLimit := cfg.HTTPConfig.RateLimit; rateLimit.Enabled {
+		routeLimits := make(map[string]models.RateLimit, len(rateLimit.Routes))
+		for route, limit := range rateLimit.Routes {
+			routeLimits[route] = models.RateLimit(limit)
+		}
+
+		rateLimitService = service.NewRateLimitService(log, rateLimitProvider, models.RateLimit(rateLimit.Default), routeLimits, models.RateLimit(rateLimit.IP))
+	}
+
+	handler := http.NewHandler(log, subscriptionService, idempotencyService, apiKeyService, rateLimitService, authenticator, cursorSecret, cfg.HTTPConfig.ExportTimeout)
 
 	httpServer := HTTPServer.NewServer(cfg.HTTPConfig.Port, cfg.HTTPConfig.Timeout, handler.InitRoutes())
 
 	return &App{
-		log:        log,
-		cfg:        cfg,
-		hTTPServer: httpServer,
+		log:                 log,
+		cfg:                 cfg,
+		hTTPServer:          httpServer,
+		subscriptionService: subscriptionService,
+		idempotencyService:  idempotencyService,
+		rateLimitService:    rateLimitService,
 	}
 }
 
 func (app *App) MustRun() {
 	shutdownError := make(chan error)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if app.cfg.TrashConfig.Retention > 0 {
+		go app.subscriptionService.PurgeTrash(ctx, app.cfg.TrashConfig.Retention, app.cfg.TrashConfig.PurgeInterval)
+	}
+
+	go app.idempotencyService.PurgeExpired(ctx, app.cfg.IdempotencyConfig.PurgeInterval)
+
+	if app.rateLimitService != nil {
+		go app.rateLimitService.PurgeIdle(ctx, app.cfg.HTTPConfig.RateLimit.PurgeInterval)
+	}
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
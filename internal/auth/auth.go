@@ -0,0 +1,65 @@
+// Package auth authenticates API callers with JWT bearer tokens and API keys.
+package auth
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"github.com/google/uuid"
+	"slices"
+	"strconv"
+)
+
+// Principal the authenticated caller. Admin callers may access subscriptions of all users and use every
+// scope and permission. Service clients authenticated with an API key have a nil UserID and are limited
+// by their scopes only, role permissions apply to users.
+type Principal struct {
+	UserID      uuid.UUID
+	Admin       bool
+	APIKeyID    int
+	Scopes      []string
+	Permissions []string
+}
+
+// userScopes the scopes of users authenticated with a JWT.
+var userScopes = []string{models.APIKeyScopeRead, models.APIKeyScopeWrite, models.APIKeyScopeReports}
+
+// NewAPIKeyPrincipal returns the principal of a service client authenticated with the key.
+func NewAPIKeyPrincipal(key *models.APIKey) *Principal {
+	return &Principal{
+		Admin:    slices.Contains(key.Scopes, models.APIKeyScopeAdmin),
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}
+}
+
+// HasScope reports whether the caller may use routes requiring the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p.Admin || slices.Contains(p.Scopes, scope)
+}
+
+// HasPermission reports whether the roles of the caller grant the permission.
+func (p *Principal) HasPermission(permission string) bool {
+	return p.Admin || p.APIKeyID != 0 || slices.Contains(p.Permissions, permission)
+}
+
+// Subject identifies the caller, the user id or the id of the API key.
+func (p *Principal) Subject() string {
+	if p.APIKeyID != 0 {
+		return "api_key:" + strconv.Itoa(p.APIKeyID)
+	}
+
+	return p.UserID.String()
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the principal.
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx, nil if the request has not been authenticated.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
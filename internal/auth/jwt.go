@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"eff-subscriptions/internal/config"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"os"
+	"slices"
+	"time"
+)
+
+// ErrInvalidToken the token is malformed, expired or its signature or claims do not match the configuration.
+var ErrInvalidToken = errors.New("invalid token")
+
+// claims the claims of an access token, sub holds the user id.
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+type Authenticator struct {
+	parser       *jwt.Parser
+	hmacSecret   []byte
+	rsaKey       *rsa.PublicKey
+	jwks         map[string]*rsa.PublicKey
+	adminRole    string
+	policy       map[string][]string
+	defaultRoles []string
+}
+
+// NewAuthenticator loads the keys and the role policy configured in cfg. Only the algorithms with a configured
+// key are accepted, without any keys every token is rejected and callers may authenticate only with API keys.
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	policy, err := newPolicy(cfg.Roles, cfg.DefaultRoles, cfg.AdminRole)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{
+		hmacSecret:   []byte(cfg.HMACSecret),
+		adminRole:    cfg.AdminRole,
+		policy:       policy,
+		defaultRoles: cfg.DefaultRoles,
+	}
+
+	var methods []string
+
+	if cfg.HMACSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if cfg.RSAPublicKeyFile != "" {
+		data, err := os.ReadFile(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		a.rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.RSAPublicKeyFile, err)
+		}
+	}
+
+	if cfg.JWKSFile != "" {
+		a.jwks, err = loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.JWKSFile, err)
+		}
+	}
+
+	if a.rsaKey != nil || len(a.jwks) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return a, nil
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	a.parser = jwt.NewParser(options...)
+
+	return a, nil
+}
+
+// Authenticate verifies the token and returns the caller it was issued to. The caller is granted the permissions
+// of the roles in the roles claim, or of the default roles if the claim is empty.
+func (a *Authenticator) Authenticate(token string) (*Principal, error) {
+	if a.parser == nil {
+		return nil, fmt.Errorf("%w: no token keys are configured", ErrInvalidToken)
+	}
+
+	var c claims
+
+	_, err := a.parser.ParseWithClaims(token, &c, a.key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userID, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sub claim must be a user id", ErrInvalidToken)
+	}
+
+	roles := c.Roles
+	if len(roles) == 0 {
+		roles = a.defaultRoles
+	}
+
+	return &Principal{
+		UserID:      userID,
+		Admin:       slices.Contains(roles, a.adminRole),
+		Scopes:      userScopes,
+		Permissions: permissions(a.policy, roles),
+	}, nil
+}
+
+// key returns the key verifying the token. RS256 tokens are verified with the JWKS key named by
+// the kid header, or with the PEM key if the kid is missing or unknown.
+func (a *Authenticator) key(token *jwt.Token) (any, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return a.hmacSecret, nil
+	}
+
+	if kid, ok := token.Header["kid"].(string); ok {
+		if key, ok := a.jwks[kid]; ok {
+			return key, nil
+		}
+	}
+
+	if a.rsaKey != nil {
+		return a.rsaKey, nil
+	}
+
+	return nil, errors.New("unknown key id")
+}
@@ -0,0 +1,206 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/config"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const hmacSecret = "test-secret"
+
+func TestAuthenticate(t *testing.T) {
+	first, second := newRSAKey(t), newRSAKey(t)
+
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{
+		HMACSecret:   hmacSecret,
+		JWKSFile:     writeJWKS(t, map[string]*rsa.PrivateKey{"first": first, "second": second}),
+		Issuer:       "eff-subscriptions",
+		AdminRole:    "admin",
+		DefaultRoles: []string{"editor", "finance"},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	user := uuid.New()
+	now := time.Now()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"sub": user.String(), "iss": "eff-subscriptions", "exp": now.Add(time.Hour).Unix()}
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		c := valid()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&first.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})
+
+	tests := []struct {
+		name      string
+		token     string
+		wantAdmin bool
+		wantErr   bool
+	}{
+		{name: "HS256", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), valid())},
+		{name: "admin role", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), with("roles", []string{"admin"})), wantAdmin: true},
+		{name: "RS256 key selected by kid", token: sign(t, jwt.SigningMethodRS256, "second", second, valid())},
+		{name: "RS256 signed with another kid", token: sign(t, jwt.SigningMethodRS256, "first", second, valid()), wantErr: true},
+		{name: "RS256 unknown kid", token: sign(t, jwt.SigningMethodRS256, "third", first, valid()), wantErr: true},
+		{name: "RS256 without kid", token: sign(t, jwt.SigningMethodRS256, "", first, valid()), wantErr: true},
+		{name: "unexpected algorithm", token: sign(t, jwt.SigningMethodHS384, "", []byte(hmacSecret), valid()), wantErr: true},
+		{name: "none algorithm", token: sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, valid()), wantErr: true},
+		{name: "HS256 signed with the RSA public key", token: sign(t, jwt.SigningMethodHS256, "first", publicKeyPEM, valid()), wantErr: true},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, "", []byte("other-secret"), valid()), wantErr: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), with("exp", now.Add(-time.Hour).Unix())), wantErr: true},
+		{name: "missing exp", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), with("exp", nil)), wantErr: true},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), with("iss", "other")), wantErr: true},
+		{name: "sub is not a user id", token: sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), with("sub", "admin")), wantErr: true},
+		{name: "malformed", token: "not-a-token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(tt.token)
+
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidToken) {
+					t.Fatalf("Authenticate: got %v, want %v", err, auth.ErrInvalidToken)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.UserID != user || principal.Admin != tt.wantAdmin {
+				t.Fatalf("Authenticate: got user %s admin %t, want user %s admin %t", principal.UserID, principal.Admin, user, tt.wantAdmin)
+			}
+			if !principal.HasPermission(auth.PermissionCreateSubscriptions) || !principal.HasPermission(auth.PermissionSumSubscriptions) {
+				t.Fatalf("Authenticate: permissions %v miss those of the default roles", principal.Permissions)
+			}
+		})
+	}
+}
+
+// TestAuthenticateRSAOnly checks that HS256 tokens are rejected without an HMAC secret, the empty secret
+// would verify a token signed with an empty key.
+func TestAuthenticateRSAOnly(t *testing.T) {
+	key := newRSAKey(t)
+
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{
+		JWKSFile:     writeJWKS(t, map[string]*rsa.PrivateKey{"key": key}),
+		AdminRole:    "admin",
+		DefaultRoles: []string{"editor"},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	claims := jwt.MapClaims{"sub": uuid.NewString(), "exp": time.Now().Add(time.Hour).Unix()}
+
+	_, err = authenticator.Authenticate(sign(t, jwt.SigningMethodRS256, "key", key, claims))
+	if err != nil {
+		t.Fatalf("Authenticate RS256: %v", err)
+	}
+
+	_, err = authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, "", []byte{}, claims))
+	if !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("Authenticate HS256 with an empty key: got %v, want %v", err, auth.ErrInvalidToken)
+	}
+}
+
+func TestAuthenticateWithoutKeys(t *testing.T) {
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{AdminRole: "admin", DefaultRoles: []string{"editor"}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	claims := jwt.MapClaims{"sub": uuid.NewString(), "exp": time.Now().Add(time.Hour).Unix()}
+
+	_, err = authenticator.Authenticate(sign(t, jwt.SigningMethodHS256, "", []byte(hmacSecret), claims))
+	if !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("Authenticate: got %v, want %v", err, auth.ErrInvalidToken)
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+
+	return key
+}
+
+// writeJWKS writes the public keys to a JWKS file and returns its path.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal JWKS: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	err = os.WriteFile(path, data, 0o600)
+	if err != nil {
+		t.Fatalf("write JWKS: %v", err)
+	}
+
+	return path
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return signed
+}
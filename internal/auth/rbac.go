@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	PermissionCreateSubscriptions = "subscriptions:create"
+	PermissionUpdateSubscriptions = "subscriptions:update"
+	PermissionDeleteSubscriptions = "subscriptions:delete"
+	PermissionSumSubscriptions    = "subscriptions:sum"
+)
+
+var Permissions = []string{
+	PermissionCreateSubscriptions,
+	PermissionUpdateSubscriptions,
+	PermissionDeleteSubscriptions,
+	PermissionSumSubscriptions,
+}
+
+// DefaultPolicy the permissions of each role used unless the configuration defines its own roles.
+// Every authenticated user may read their subscriptions, the admin role is granted all permissions.
+var DefaultPolicy = map[string][]string{
+	"viewer":  {},
+	"editor":  {PermissionCreateSubscriptions, PermissionUpdateSubscriptions, PermissionDeleteSubscriptions},
+	"finance": {PermissionSumSubscriptions},
+}
+
+// newPolicy checks that the roles grant only known permissions and that the default roles are defined.
+func newPolicy(roles map[string][]string, defaultRoles []string, adminRole string) (map[string][]string, error) {
+	if len(roles) == 0 {
+		roles = DefaultPolicy
+	}
+
+	for role, permissions := range roles {
+		for _, permission := range permissions {
+			if !slices.Contains(Permissions, permission) {
+				return nil, fmt.Errorf("role %q: unknown permission %q", role, permission)
+			}
+		}
+	}
+
+	for _, role := range defaultRoles {
+		if _, ok := roles[role]; !ok && role != adminRole {
+			return nil, fmt.Errorf("default role %q is not defined", role)
+		}
+	}
+
+	return roles, nil
+}
+
+// permissions returns the permissions granted by the roles, roles missing from the policy grant nothing.
+func permissions(policy map[string][]string, roles []string) []string {
+	var granted []string
+
+	for _, role := range roles {
+		for _, permission := range policy[role] {
+			if !slices.Contains(granted, permission) {
+				granted = append(granted, permission)
+			}
+		}
+	}
+
+	return granted
+}
@@ -6,10 +6,28 @@ import (
 	"time"
 )
 
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+)
+
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
 type Config struct {
-	PostgresDBConfig DBConfig   `yaml:"postgresDB"`
-	HTTPConfig       HTTPConfig `yaml:"http"`
-	Env              string     `yaml:"env"`
+	Storage           string            `yaml:"storage" env-default:"postgres"`
+	PostgresDBConfig  DBConfig          `yaml:"postgresDB"`
+	SQLiteDBConfig    SQLiteConfig      `yaml:"sqliteDB"`
+	HTTPConfig        HTTPConfig        `yaml:"http"`
+	TrashConfig       TrashConfig       `yaml:"trash"`
+	IdempotencyConfig IdempotencyConfig `yaml:"idempotency"`
+	AuthConfig        AuthConfig        `yaml:"auth"`
+	PaginationConfig  PaginationConfig  `yaml:"pagination"`
+	MigrationsConfig  MigrationsConfig  `yaml:"migrations"`
+	Env               string            `yaml:"env"`
 }
 
 type DBConfig struct {
@@ -22,11 +40,101 @@ type DBConfig struct {
 	MaxOpenConns int           `yaml:"maxOpenConns"`
 	MaxIdleConns int           `yaml:"maxIdleConns"`
 	MaxIdleTime  time.Duration `yaml:"maxIdleTime"`
+	Timeouts     QueryTimeouts `yaml:"queryTimeouts"`
+}
+
+// QueryTimeouts limits how long a storage operation may run. Read and Write apply to single
+// requests, Report to aggregations such as the subscriptions sum, Export to reading the subscriptions
+// of an export and Maintenance to bulk operations such as purging the trash and importing exchange rates.
+type QueryTimeouts struct {
+	Read        time.Duration `yaml:"read" env-default:"3s"`
+	Write       time.Duration `yaml:"write" env-default:"3s"`
+	Report      time.Duration `yaml:"report" env-default:"10s"`
+	Export      time.Duration `yaml:"export" env-default:"5m"`
+	Maintenance time.Duration `yaml:"maintenance" env-default:"30s"`
+}
+
+// SQLiteConfig location of the SQLite database file. The file is created if it does not exist.
+type SQLiteConfig struct {
+	Path     string        `yaml:"path" env-default:"eff-subscriptions.db"`
+	Timeouts QueryTimeouts `yaml:"queryTimeouts"`
 }
 
+// HTTPConfig Timeout limits reading a request and writing its response. ExportTimeout replaces it
+// for writing exports, which are streamed while the subscriptions are read.
 type HTTPConfig struct {
-	Port    int           `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Port          int             `yaml:"port"`
+	Timeout       time.Duration   `yaml:"timeout"`
+	ExportTimeout time.Duration   `yaml:"exportTimeout" env-default:"5m"`
+	RateLimit     RateLimitConfig `yaml:"rateLimit"`
+}
+
+// RateLimitConfig limits requests of each client, identified by the API key, the user or the IP address,
+// with token buckets. Routes overrides Default for routes named "METHOD /path" after the route patterns,
+// e.g. "POST /v1/subscriptions", each of them gets a bucket of its own. IP limits requests of each IP address
+// before the credentials are checked, so that requests with missing or invalid credentials are limited too.
+// Store keeps the buckets in memory of each instance or in the postgres database shared by all instances.
+// Buckets unused long enough to refill are deleted every PurgeInterval.
+type RateLimitConfig struct {
+	Enabled       bool                 `yaml:"enabled"`
+	Store         string               `yaml:"store" env-default:"memory"`
+	Default       RateLimit            `yaml:"default"`
+	IP            RateLimit            `yaml:"ip"`
+	Routes        map[string]RateLimit `yaml:"routes"`
+	PurgeInterval time.Duration        `yaml:"purgeInterval" env-default:"10m"`
+}
+
+// RateLimit a bucket of Burst requests refilled at Rate requests per second.
+type RateLimit struct {
+	Rate  float64 `yaml:"rate" env-default:"10"`
+	Burst int     `yaml:"burst" env-default:"20"`
+}
+
+// TrashConfig controls purging of deleted subscriptions.
+// Purging is disabled when Retention is zero.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purgeInterval" env-default:"1h"`
+}
+
+// IdempotencyConfig TTL how long responses of requests with an Idempotency-Key header are replayed,
+// expired keys are deleted every PurgeInterval. A key of a request unfinished after LockTimeout may be reused.
+type IdempotencyConfig struct {
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`
+	LockTimeout   time.Duration `yaml:"lockTimeout" env-default:"1m"`
+	PurgeInterval time.Duration `yaml:"purgeInterval" env-default:"1h"`
+}
+
+// AuthConfig controls authentication of API requests with JWT bearer tokens. Tokens are signed with HS256
+// using HMACSecret or with RS256 using the key from RSAPublicKeyFile (PEM) or the keys of a local JWKSFile
+// selected by kid. The sub claim holds the user id, callers with AdminRole in the roles claim may access
+// subscriptions of all users. Roles maps role names to the permissions they grant, users without roles in
+// the token get DefaultRoles. The built-in policy of viewer, editor and finance roles is used if Roles is empty.
+// Without token keys only API keys are accepted. Authentication is disabled unless Enabled is set, API keys
+// are checked either way.
+type AuthConfig struct {
+	Enabled          bool                `yaml:"enabled"`
+	HMACSecret       string              `yaml:"hmacSecret" env:"JWT_HMAC_SECRET"`
+	RSAPublicKeyFile string              `yaml:"rsaPublicKeyFile"`
+	JWKSFile         string              `yaml:"jwksFile"`
+	Issuer           string              `yaml:"issuer"`
+	Audience         string              `yaml:"audience"`
+	AdminRole        string              `yaml:"adminRole" env-default:"admin"`
+	Roles            map[string][]string `yaml:"roles"`
+	DefaultRoles     []string            `yaml:"defaultRoles" env-default:"editor,finance"`
+}
+
+// PaginationConfig CursorSecret signs pagination cursors. If it is empty a random secret is generated
+// on start, so cursors issued before a restart are rejected.
+type PaginationConfig struct {
+	CursorSecret string `yaml:"cursorSecret" env:"CURSOR_SECRET"`
+}
+
+// MigrationsConfig controls schema migrations on server start. If ApplyOnStart is not set
+// the server refuses to start with pending migrations unless AllowOutdated is set.
+type MigrationsConfig struct {
+	ApplyOnStart  bool `yaml:"applyOnStart"`
+	AllowOutdated bool `yaml:"allowOutdated"`
 }
 
 func MustRead(configPath string) *Config {
@@ -44,5 +152,39 @@ func MustRead(configPath string) *Config {
 		panic("incorrect env file: " + err.Error())
 	}
 
+	if cfg.Storage != StoragePostgres && cfg.Storage != StorageMemory && cfg.Storage != StorageSQLite {
+		panic("unknown storage: " + cfg.Storage)
+	}
+
+	if cfg.HTTPConfig.RateLimit.Enabled {
+		mustValidateRateLimits(&cfg)
+	}
+
 	return &cfg
 }
+
+func mustValidateRateLimits(cfg *Config) {
+	rateLimit := cfg.HTTPConfig.RateLimit
+
+	if rateLimit.Store != RateLimitStoreMemory && rateLimit.Store != RateLimitStorePostgres {
+		panic("unknown rate limit store: " + rateLimit.Store)
+	}
+
+	if rateLimit.Store == RateLimitStorePostgres && cfg.Storage != StoragePostgres {
+		panic("postgres rate limit store requires postgres storage")
+	}
+
+	if rateLimit.Default.Rate <= 0 || rateLimit.Default.Burst < 1 {
+		panic("default rate limit must have a positive rate and burst")
+	}
+
+	if rateLimit.IP.Rate <= 0 || rateLimit.IP.Burst < 1 {
+		panic("ip rate limit must have a positive rate and burst")
+	}
+
+	for route, limit := range rateLimit.Routes {
+		if limit.Rate <= 0 || limit.Burst < 1 {
+			panic("rate limit of " + route + " must have a positive rate and burst")
+		}
+	}
+}
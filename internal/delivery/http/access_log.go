@@ -0,0 +1,45 @@
+package http
+
+import (
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/logging"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// logRequests assigns the request its id, returns it in the X-Request-ID header and logs the request
+// once it has been served. Server errors are logged at the error level.
+func (h *Handler) logRequests(c *gin.Context) {
+	start := time.Now()
+
+	id := requestID(c)
+	c.Header("X-Request-ID", id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+
+	c.Next()
+
+	status := c.Writer.Status()
+
+	attrs := []slog.Attr{
+		slog.String("method", c.Request.Method),
+		slog.String("path", c.Request.URL.Path),
+		slog.String("route", c.FullPath()),
+		slog.Int("status", status),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("bytes", max(c.Writer.Size(), 0)),
+		slog.String("client", c.ClientIP()),
+	}
+
+	if principal := auth.FromContext(c.Request.Context()); principal != nil {
+		attrs = append(attrs, slog.String("user", principal.Subject()))
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	h.log.LogAttrs(c.Request.Context(), level, "request served", attrs...)
+}
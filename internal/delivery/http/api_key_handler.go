@@ -0,0 +1,123 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// createAPIKey godoc
+// @Summary Create API key
+// @Description Create a key for a service client. The key is returned only in this response, only its hash is stored
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateAPIKeyRequest true "API key"
+// @Success 201 {object} models.APIKeyResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/api-keys [post]
+func (h *Handler) createAPIKey(c *gin.Context) {
+	var input models.CreateAPIKeyRequest
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	key := &models.APIKey{
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+
+	v := validator.New()
+
+	if models.ValidateAPIKey(v, key); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	token, err := h.apiKeyService.Create(c.Request.Context(), key)
+	if err != nil {
+		h.serverErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIKeyResponse{APIKey: key, Key: token})
+}
+
+// listAPIKeys godoc
+// @Summary List API keys
+// @Description Return all API keys including revoked and expired ones, the keys themselves are not returned
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.APIKeysListResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/api-keys [get]
+func (h *Handler) listAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.GetAll(c.Request.Context())
+	if err != nil {
+		h.serverErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeysListResponse{APIKeys: keys})
+}
+
+// revokeAPIKey godoc
+// @Summary Revoke API key
+// @Description Revoke an API key, requests with the key are rejected afterwards
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID API key"
+// @Success 200 {object} models.DataResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/api-keys/{id} [delete]
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	err = h.apiKeyService.Revoke(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.DataResponse{Data: "api key successfully revoked"})
+}
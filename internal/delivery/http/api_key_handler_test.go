@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/domain/models"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// apiKeyAuthenticators API keys are checked whether authentication is enabled or not.
+var apiKeyAuthenticators = map[string]func(t *testing.T) *auth.Authenticator{
+	"auth enabled":  newTestAuthenticator,
+	"auth disabled": func(t *testing.T) *auth.Authenticator { return nil },
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	for name, newAuthenticator := range apiKeyAuthenticators {
+		t.Run(name, func(t *testing.T) {
+			s := newTestServer(t, newAuthenticator(t), nil)
+
+			expiresAt := time.Now().Add(-time.Minute)
+
+			read := s.createAPIKey(t, &models.APIKey{Name: "read", Scopes: []string{models.APIKeyScopeRead}})
+			write := s.createAPIKey(t, &models.APIKey{Name: "write", Scopes: []string{models.APIKeyScopeRead, models.APIKeyScopeWrite}})
+			admin := s.createAPIKey(t, &models.APIKey{Name: "admin", Scopes: []string{models.APIKeyScopeAdmin}})
+			expired := s.createAPIKey(t, &models.APIKey{Name: "expired", Scopes: []string{models.APIKeyScopeRead}, ExpiresAt: &expiresAt})
+
+			revokedKey := &models.APIKey{Name: "revoked", Scopes: []string{models.APIKeyScopeRead}}
+			revoked := s.createAPIKey(t, revokedKey)
+			assertStatus(t, s.do(http.MethodDelete, fmt.Sprintf("/v1/api-keys/%d", revokedKey.ID), admin, ""), http.StatusOK)
+
+			subscription := fmt.Sprintf(`{"service_name":"Yandex Plus","price":400,"user_id":"%s","start_date":"07-2025"}`, uuid.New())
+
+			tests := []struct {
+				name   string
+				method string
+				target string
+				body   string
+				token  string
+				want   int
+			}{
+				{"read key reads", http.MethodGet, "/v1/subscriptions", "", read, http.StatusOK},
+				{"read key writes", http.MethodPost, "/v1/subscriptions", subscription, read, http.StatusForbidden},
+				{"read key imports", http.MethodPost, "/v1/subscriptions/import", "", read, http.StatusForbidden},
+				{"read key exports", http.MethodGet, "/v1/subscriptions/export", "", read, http.StatusForbidden},
+				{"write key writes", http.MethodPost, "/v1/subscriptions", subscription, write, http.StatusCreated},
+				{"expired key", http.MethodGet, "/v1/subscriptions", "", expired, http.StatusUnauthorized},
+				{"revoked key", http.MethodGet, "/v1/subscriptions", "", revoked, http.StatusUnauthorized},
+				{"read key lists keys", http.MethodGet, "/v1/api-keys", "", read, http.StatusForbidden},
+				{"admin key lists keys", http.MethodGet, "/v1/api-keys", "", admin, http.StatusOK},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					assertStatus(t, s.do(tt.method, tt.target, tt.token, tt.body), tt.want)
+				})
+			}
+		})
+	}
+}
+
+// TestAPIKeysRequireAuthentication checks that keys cannot be managed anonymously, even with authentication disabled.
+func TestAPIKeysRequireAuthentication(t *testing.T) {
+	for name, newAuthenticator := range apiKeyAuthenticators {
+		t.Run(name, func(t *testing.T) {
+			s := newTestServer(t, newAuthenticator(t), nil)
+
+			key := &models.APIKey{Name: "read", Scopes: []string{models.APIKeyScopeRead}}
+			s.createAPIKey(t, key)
+
+			requests := []struct {
+				method string
+				target string
+				body   string
+			}{
+				{http.MethodGet, "/v1/api-keys", ""},
+				{http.MethodPost, "/v1/api-keys", `{"name":"admin","scopes":["admin"]}`},
+				{http.MethodDelete, fmt.Sprintf("/v1/api-keys/%d", key.ID), ""},
+			}
+
+			for _, r := range requests {
+				t.Run(r.method, func(t *testing.T) {
+					assertStatus(t, s.do(r.method, r.target, "", r.body), http.StatusUnauthorized)
+				})
+			}
+		})
+	}
+}
+
+// createAPIKey stores the key and returns its token.
+func (s *testServer) createAPIKey(t *testing.T, key *models.APIKey) string {
+	t.Helper()
+
+	token, err := s.apiKeys.Create(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	return token
+}
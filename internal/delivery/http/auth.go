@@ -0,0 +1,157 @@
+package http
+
+import (
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/service"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"strings"
+)
+
+// authenticate verifies the bearer token of the request and stores the caller in the request context.
+// Tokens starting with the API key prefix are checked against the stored API keys, other tokens must be JWTs.
+// When authentication is disabled only API keys are checked, other requests are served anonymously.
+func (h *Handler) authenticate(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+
+	scheme, token, _ := strings.Cut(header, " ")
+	bearer := strings.EqualFold(scheme, "Bearer")
+	token = strings.TrimSpace(token)
+
+	var principal *auth.Principal
+
+	switch {
+	case bearer && strings.HasPrefix(token, models.APIKeyPrefix):
+		key, err := h.apiKeyService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidAPIKey):
+				h.invalidAuthenticationTokenResponse(c)
+			default:
+				h.serverErrorResponse(c, err)
+			}
+			return
+		}
+
+		principal = auth.NewAPIKeyPrincipal(key)
+	case h.authenticator == nil:
+		return
+	case header == "":
+		h.authenticationRequiredResponse(c)
+		return
+	case !bearer:
+		h.invalidAuthenticationTokenResponse(c)
+		return
+	default:
+		var err error
+
+		principal, err = h.authenticator.Authenticate(token)
+		if err != nil {
+			h.invalidAuthenticationTokenResponse(c)
+			return
+		}
+	}
+
+	// The caller is recorded in the subscription history as the author of the changes.
+	ctx := repository.WithActor(auth.NewContext(c.Request.Context(), principal), principal.Subject())
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// requireAuthentication rejects anonymous requests, which are served when authentication is disabled.
+func (h *Handler) requireAuthentication(c *gin.Context) {
+	if auth.FromContext(c.Request.Context()) == nil {
+		h.authenticationRequiredResponse(c)
+	}
+}
+
+// requireScope rejects callers whose token does not grant the scope.
+func (h *Handler) requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c.Request.Context())
+		if principal != nil && !principal.HasScope(scope) {
+			h.notPermittedResponse(c)
+		}
+	}
+}
+
+// requirePermission rejects callers whose roles do not grant the permission.
+func (h *Handler) requirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.FromContext(c.Request.Context())
+		if principal != nil && !principal.HasPermission(permission) {
+			h.notPermittedResponse(c)
+		}
+	}
+}
+
+// requireAllUsers rejects callers who may access only their own subscriptions.
+func (h *Handler) requireAllUsers(c *gin.Context) {
+	if _, scoped := scopedUserID(c); scoped {
+		h.notPermittedResponse(c)
+	}
+}
+
+// scopedUserID returns the id of the caller if they may access only their own subscriptions.
+// Admins, service clients and requests served with authentication disabled are not scoped.
+func scopedUserID(c *gin.Context) (uuid.UUID, bool) {
+	principal := auth.FromContext(c.Request.Context())
+	if principal == nil || principal.Admin || principal.APIKeyID != 0 {
+		return uuid.Nil, false
+	}
+
+	return principal.UserID, true
+}
+
+// ownsSubscription reports whether the caller may access the subscription.
+func ownsSubscription(c *gin.Context, subscription *models.Subscription) bool {
+	userID, scoped := scopedUserID(c)
+	return !scoped || subscription.UserID == userID
+}
+
+// checkSubscriptionOwner reports whether a scoped caller owns the subscription. Subscriptions of other users
+// are reported as not found, errors are sent to the client.
+func (h *Handler) checkSubscriptionOwner(c *gin.Context, id int) bool {
+	if _, scoped := scopedUserID(c); !scoped {
+		return true
+	}
+
+	subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return false
+	}
+
+	if !ownsSubscription(c, subscription) {
+		h.notFoundResponse(c)
+		return false
+	}
+
+	return true
+}
+
+// scopeSubscriptionsFilter limits the filter to the subscriptions of a scoped caller. It reports false
+// if the filter asks for subscriptions of other users.
+func scopeSubscriptionsFilter(c *gin.Context, filter *models.SubscriptionsFilter) bool {
+	userID, scoped := scopedUserID(c)
+	if !scoped {
+		return true
+	}
+
+	for _, id := range filter.UserIDs {
+		if id != userID {
+			return false
+		}
+	}
+
+	filter.UserIDs = []uuid.UUID{userID}
+
+	return true
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestServer(t, newTestAuthenticator(t), nil)
+	user := uuid.New()
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"not a bearer token", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"invalid token", "Bearer not-a-token", http.StatusUnauthorized},
+		{"unknown API key", "Bearer " + models.APIKeyPrefix + "unknown", http.StatusUnauthorized},
+		{"valid token", "Bearer " + userToken(t, user), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header []string
+			if tt.header != "" {
+				header = []string{"Authorization", tt.header}
+			}
+
+			assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions", "", "", header...), tt.want)
+		})
+	}
+}
+
+func TestSubscriptionOwner(t *testing.T) {
+	s := newTestServer(t, newTestAuthenticator(t), nil)
+	userA, userB := uuid.New(), uuid.New()
+	tokenA, admin := userToken(t, userA), userToken(t, uuid.New(), "admin")
+
+	own := s.insert(t, "Yandex Plus", 400, userA)
+	other := s.insert(t, "Netflix", 900, userB)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		token  string
+		want   int
+	}{
+		{"read own", http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d", own.ID), "", tokenA, http.StatusOK},
+		{"read other", http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d", other.ID), "", tokenA, http.StatusNotFound},
+		{"patch other", http.MethodPatch, fmt.Sprintf("/v1/subscriptions/%d", other.ID), `{"price":1}`, tokenA, http.StatusNotFound},
+		{"delete other", http.MethodDelete, fmt.Sprintf("/v1/subscriptions/%d", other.ID), "", tokenA, http.StatusNotFound},
+		{"history of other", http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d/history", other.ID), "", tokenA, http.StatusNotFound},
+		{"admin reads other", http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d", other.ID), "", admin, http.StatusOK},
+		{"trash", http.MethodGet, "/v1/subscriptions/trash", "", tokenA, http.StatusForbidden},
+		{"admin trash", http.MethodGet, "/v1/subscriptions/trash", "", admin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertStatus(t, s.do(tt.method, tt.target, tt.token, tt.body), tt.want)
+		})
+	}
+
+	// The requests of user A must not have changed the subscription of user B.
+	w := s.do(http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d", other.ID), admin, "")
+	assertStatus(t, w, http.StatusOK)
+
+	var response struct {
+		Subscription struct {
+			Price   int `json:"price"`
+			Version int `json:"version"`
+		} `json:"subscription"`
+	}
+	decodeBody(t, w, &response)
+
+	if response.Subscription.Price != 900 || response.Subscription.Version != other.Version {
+		t.Fatalf("subscription of user B: got price %d version %d, want price 900 version %d",
+			response.Subscription.Price, response.Subscription.Version, other.Version)
+	}
+}
+
+func TestSubscriptionsFilterScope(t *testing.T) {
+	s := newTestServer(t, newTestAuthenticator(t), nil)
+	userA, userB := uuid.New(), uuid.New()
+	tokenA, admin := userToken(t, userA), userToken(t, uuid.New(), "admin")
+
+	own := s.insert(t, "Yandex Plus", 400, userA)
+	other := s.insert(t, "Netflix", 900, userB)
+
+	tests := []struct {
+		name   string
+		target string
+		token  string
+		want   []int
+	}{
+		{"user", "/v1/subscriptions", tokenA, []int{own.ID}},
+		{"user filters by own id", "/v1/subscriptions?user_id=" + userA.String(), tokenA, []int{own.ID}},
+		{"admin", "/v1/subscriptions", admin, []int{own.ID, other.ID}},
+		{"admin filters by user", "/v1/subscriptions?user_id=" + userB.String(), admin, []int{other.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := listIDs(t, s.do(http.MethodGet, tt.target, tt.token, ""))
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("ids: got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	t.Run("user filters by other id", func(t *testing.T) {
+		target := fmt.Sprintf("/v1/subscriptions?user_id=%s,%s", userA, userB)
+		assertStatus(t, s.do(http.MethodGet, target, tokenA, ""), http.StatusForbidden)
+	})
+
+	t.Run("user sums other", func(t *testing.T) {
+		target := "/v1/sum-subscriptions-price?start_date=01-2025&end_date=12-2025&user_id=" + userB.String()
+		assertStatus(t, s.do(http.MethodGet, target, tokenA, ""), http.StatusForbidden)
+	})
+}
+
+func TestAuthenticationDisabled(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+
+	first := s.insert(t, "Yandex Plus", 400, uuid.New())
+	second := s.insert(t, "Netflix", 900, uuid.New())
+
+	ids := listIDs(t, s.do(http.MethodGet, "/v1/subscriptions", "", ""))
+	if want := []int{first.ID, second.ID}; !slices.Equal(ids, want) {
+		t.Fatalf("ids: got %v, want %v", ids, want)
+	}
+
+	assertStatus(t, s.do(http.MethodGet, fmt.Sprintf("/v1/subscriptions/%d", second.ID), "", ""), http.StatusOK)
+	assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions/trash", "", ""), http.StatusOK)
+
+	// A JWT cannot be checked without an authenticator.
+	assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions", userToken(t, uuid.New()), ""), http.StatusOK)
+}
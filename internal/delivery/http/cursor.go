@@ -0,0 +1,85 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/validator"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"strings"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor returns an opaque token of the cursor signed with the cursor secret,
+// so that clients cannot forge positions.
+func (h *Handler) encodeCursor(cursor models.Cursor) string {
+	payload, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(h.signCursor(payload))
+}
+
+func (h *Handler) decodeCursor(token string) (*models.Cursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, h.signCursor(payload)) {
+		return nil, errInvalidCursor
+	}
+
+	var cursor models.Cursor
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+func (h *Handler) signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, h.cursorSecret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// readCursor reads an optional cursor token from the query string.
+func (h *Handler) readCursor(c *gin.Context, key string, v *validator.Validator) *models.Cursor {
+	token := c.Query(key)
+	if token == "" {
+		return nil
+	}
+
+	cursor, err := h.decodeCursor(token)
+	if err != nil {
+		v.AddError(key, "must be a valid cursor")
+		return nil
+	}
+
+	return cursor
+}
+
+// setCursors fills the cursors of the pages around the listed subscriptions.
+func (h *Handler) setCursors(metadata *models.Metadata, filters models.Filters, subscriptions []*models.Subscription) {
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	if metadata.HasNext {
+		metadata.NextCursor = h.encodeCursor(models.NewSubscriptionCursor(filters, subscriptions[len(subscriptions)-1]))
+	}
+	if metadata.HasPrev {
+		metadata.PrevCursor = h.encodeCursor(models.NewSubscriptionCursor(filters, subscriptions[0]))
+	}
+}
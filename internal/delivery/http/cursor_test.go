@@ -0,0 +1,116 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCursorPagination(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+
+	first := s.insert(t, "Yandex Plus", 100, uuid.New())
+	second := s.insert(t, "Netflix", 200, uuid.New())
+	third := s.insert(t, "Spotify", 300, uuid.New())
+
+	w := s.do(http.MethodGet, "/v1/subscriptions?sort=price&page_size=1", "", "")
+	if ids := listIDs(t, w); !slices.Equal(ids, []int{first.ID}) {
+		t.Fatalf("first page: got %v, want %v", ids, []int{first.ID})
+	}
+
+	next := listMetadata(t, w).NextCursor
+
+	w = s.do(http.MethodGet, "/v1/subscriptions?sort=price&page_size=2&after="+url.QueryEscape(next), "", "")
+	if ids := listIDs(t, w); !slices.Equal(ids, []int{second.ID, third.ID}) {
+		t.Fatalf("page after the cursor: got %v, want %v", ids, []int{second.ID, third.ID})
+	}
+
+	prev := listMetadata(t, w).PrevCursor
+
+	w = s.do(http.MethodGet, "/v1/subscriptions?sort=price&page_size=2&before="+url.QueryEscape(prev), "", "")
+	if ids := listIDs(t, w); !slices.Equal(ids, []int{first.ID}) {
+		t.Fatalf("page before the cursor: got %v, want %v", ids, []int{first.ID})
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+
+	s.insert(t, "Yandex Plus", 100, uuid.New())
+	s.insert(t, "Netflix", 200, uuid.New())
+
+	cursor := listMetadata(t, s.do(http.MethodGet, "/v1/subscriptions?sort=price&page_size=1", "", "")).NextCursor
+
+	payload, signature, _ := strings.Cut(cursor, ".")
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("decode cursor payload: %v", err)
+	}
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(decoded), "100", "150", 1)))
+
+	otherSecret := &Handler{cursorSecret: []byte("other-secret")}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"tampered payload", "sort=price&after=" + tampered + "." + signature, "must be a valid cursor"},
+		{"tampered signature", "sort=price&after=" + payload + "." + flipFirstChar(signature), "must be a valid cursor"},
+		{"missing signature", "sort=price&after=" + payload, "must be a valid cursor"},
+		{"not base64", "sort=price&after=" + url.QueryEscape("*."+signature), "must be a valid cursor"},
+		{"other secret", "sort=price&after=" + otherSecret.encodeCursor(models.Cursor{Sort: "price", Values: []string{"150", "1"}}), "must be a valid cursor"},
+		{"tampered before", "sort=price&before=" + tampered + "." + signature, "must be a valid cursor"},
+		{"other sort", "sort=-price&after=" + cursor, "cursor does not match the sort order"},
+		{"other sort columns", "sort=price,service_name&after=" + cursor, "cursor does not match the sort order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(http.MethodGet, "/v1/subscriptions?"+tt.query, "", "")
+			assertStatus(t, w, http.StatusUnprocessableEntity)
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			decodeBody(t, w, &response)
+
+			key := "after"
+			if strings.Contains(tt.query, "before=") {
+				key = "before"
+			}
+
+			if response.Error[key] != tt.want {
+				t.Fatalf("error of %s: got %q, want %q", key, response.Error[key], tt.want)
+			}
+		})
+	}
+}
+
+func listMetadata(t *testing.T, w *httptest.ResponseRecorder) models.Metadata {
+	t.Helper()
+
+	assertStatus(t, w, http.StatusOK)
+
+	var response models.SubscriptionsListResponse
+	decodeBody(t, w, &response)
+
+	return response.Metadata
+}
+
+// flipFirstChar changes the first character of a base64 string, and so its first byte, to another valid one.
+func flipFirstChar(s string) string {
+	first := "A"
+	if strings.HasPrefix(s, "A") {
+		first = "B"
+	}
+
+	return first + s[1:]
+}
@@ -1,37 +1,87 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
 
+// statusClientClosedRequest non-standard status used when the client closed the connection
+// before the response was ready.
+const statusClientClosedRequest = 499
+
 // errorResponse error response struct
 // @Description error message
 type errorResponse struct {
-	Error any `json:"error"`
+	Error     any    `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func (h *Handler) logError(c *gin.Context, err error) {
 	method := c.Request.Method
 	uri := c.Request.RequestURI
 
-	h.log.Error(err.Error(), "method", method, "uri", uri)
+	h.log.ErrorContext(c.Request.Context(), err.Error(), "method", method, "uri", uri)
 }
 
+// errorResponse sends the message as an errorResponse, or as problemDetails if the client prefers
+// application/problem+json. The message is a string or a map of field validation errors.
 func (h *Handler) errorResponse(c *gin.Context, status int, message any) {
-	env := errorResponse{message}
+	if acceptsProblem(c) {
+		c.Header("Content-Type", mimeProblemJSON)
+		c.AbortWithStatusJSON(status, newProblem(c, status, message))
+		return
+	}
+
+	env := errorResponse{Error: message, RequestID: requestID(c)}
 
 	c.AbortWithStatusJSON(status, env)
 }
 
+// serverErrorResponse reports an unexpected error. Operations interrupted by cancellation
+// or timeout are reported with their own statuses.
 func (h *Handler) serverErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		ctx := c.Request.Context()
+
+		switch {
+		case ctx.Err() != nil && errors.Is(context.Cause(ctx), context.Canceled):
+			h.clientClosedRequestResponse(c)
+		case ctx.Err() != nil:
+			h.serviceUnavailableResponse(c)
+		default:
+			h.timeoutResponse(c, err)
+		}
+		return
+	}
+
 	h.logError(c, err)
 
 	message := "the server encountered a problem and could not process your request"
 	h.errorResponse(c, http.StatusInternalServerError, message)
 }
 
+// clientClosedRequestResponse the client will not read the response, the status is set for access logs.
+func (h *Handler) clientClosedRequestResponse(c *gin.Context) {
+	c.AbortWithStatus(statusClientClosedRequest)
+}
+
+func (h *Handler) serviceUnavailableResponse(c *gin.Context) {
+	message := "the server is shutting down, please retry your request later"
+	c.Header("Retry-After", "5")
+	h.errorResponse(c, http.StatusServiceUnavailable, message)
+}
+
+func (h *Handler) timeoutResponse(c *gin.Context, err error) {
+	h.logError(c, err)
+
+	message := "the server did not complete your request in time, please try again"
+	h.errorResponse(c, http.StatusGatewayTimeout, message)
+}
+
 func (h *Handler) notFoundResponse(c *gin.Context) {
 	message := "the requested resource could not be found"
 	h.errorResponse(c, http.StatusNotFound, message)
@@ -54,3 +104,37 @@ func (h *Handler) editConflictResponse(c *gin.Context) {
 func (h *Handler) failedValidationResponse(c *gin.Context, errs map[string]string) {
 	h.errorResponse(c, http.StatusUnprocessableEntity, errs)
 }
+
+func (h *Handler) contentTooLargeResponse(c *gin.Context, limit int64) {
+	message := fmt.Sprintf("the request body must not be larger than %d bytes", limit)
+	h.errorResponse(c, http.StatusRequestEntityTooLarge, message)
+}
+
+func (h *Handler) preconditionFailedResponse(c *gin.Context) {
+	message := "the subscription has been modified, read it again to get the current ETag"
+	h.errorResponse(c, http.StatusPreconditionFailed, message)
+}
+
+func (h *Handler) authenticationRequiredResponse(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+
+	message := "you must be authenticated to access this resource"
+	h.errorResponse(c, http.StatusUnauthorized, message)
+}
+
+func (h *Handler) invalidAuthenticationTokenResponse(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+
+	message := "invalid or expired authentication token"
+	h.errorResponse(c, http.StatusUnauthorized, message)
+}
+
+func (h *Handler) notPermittedResponse(c *gin.Context) {
+	message := "your user account doesn't have the necessary permissions to access this resource"
+	h.errorResponse(c, http.StatusForbidden, message)
+}
+
+func (h *Handler) rateLimitExceededResponse(c *gin.Context) {
+	message := "rate limit exceeded"
+	h.errorResponse(c, http.StatusTooManyRequests, message)
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// subscriptionETag returns the strong entity tag of the subscription, it changes with every version.
+func subscriptionETag(subscription *models.Subscription) string {
+	return `"` + strconv.Itoa(subscription.Version) + `"`
+}
+
+// matchETag reports whether the entity tags of an If-Match or If-None-Match header contain etag.
+// Weak tags are compared only if weak is set, "*" matches any tag.
+func matchETag(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch reports whether the If-Match header of the request, if any, matches the subscription.
+// Otherwise it sends 412 Precondition Failed.
+func (h *Handler) checkIfMatch(c *gin.Context, subscription *models.Subscription) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchETag(header, subscriptionETag(subscription), false) {
+		return true
+	}
+
+	h.preconditionFailedResponse(c)
+	return false
+}
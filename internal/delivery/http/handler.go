@@ -1,11 +1,14 @@
 package http
 
 import (
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/domain/models"
 	"eff-subscriptions/internal/service"
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"log/slog"
+	"time"
 
 	_ "eff-subscriptions/docs"
 )
@@ -13,27 +16,64 @@ import (
 type Handler struct {
 	log                 *slog.Logger
 	subscriptionService *service.SubscriptionService
+	idempotencyService  *service.IdempotencyService
+	apiKeyService       *service.APIKeyService
+	rateLimitService    *service.RateLimitService
+	authenticator       *auth.Authenticator
+	cursorSecret        []byte
+	exportTimeout       time.Duration
 }
 
-func NewHandler(log *slog.Logger, subscriptionService *service.SubscriptionService) *Handler {
+// NewHandler creates the API handler. Only API keys are checked if authenticator is nil and requests are not rate
+// limited if rateLimitService is nil. Export responses may be written for exportTimeout.
+func NewHandler(log *slog.Logger, subscriptionService *service.SubscriptionService, idempotencyService *service.IdempotencyService, apiKeyService *service.APIKeyService, rateLimitService *service.RateLimitService, authenticator *auth.Authenticator, cursorSecret []byte, exportTimeout time.Duration) *Handler {
 	return &Handler{
 		log:                 log,
 		subscriptionService: subscriptionService,
+		idempotencyService:  idempotencyService,
+		apiKeyService:       apiKeyService,
+		rateLimitService:    rateLimitService,
+		authenticator:       authenticator,
+		cursorSecret:        cursorSecret,
+		exportTimeout:       exportTimeout,
 	}
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
-	mux := gin.Default()
+	mux := gin.New()
+	mux.Use(h.logRequests, gin.Recovery())
 
 	mux.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
-	mux.GET("/v1/subscriptions", h.listSubscriptions)
-	mux.POST("/v1/subscriptions", h.createSubscription)
-	mux.GET("/v1/subscriptions/:id", h.readSubscription)
-	mux.PATCH("/v1/subscriptions/:id", h.updateSubscription)
-	mux.DELETE("/v1/subscriptions/:id", h.deleteSubscription)
+	api := mux.Group("/v1", h.rateLimitIP, h.authenticate, h.rateLimit)
 
-	mux.GET("/v1/sum-subscriptions-price", h.sumSubscriptionsPrice)
+	read := h.requireScope(models.APIKeyScopeRead)
+	write := h.requireScope(models.APIKeyScopeWrite)
+	reports := h.requireScope(models.APIKeyScopeReports)
+	admin := h.requireScope(models.APIKeyScopeAdmin)
+
+	api.GET("/subscriptions", read, h.listSubscriptions)
+	api.POST("/subscriptions", write, h.requirePermission(auth.PermissionCreateSubscriptions), h.idempotent, h.createSubscription)
+	api.POST("/subscriptions:action", write, h.requireAllUsers, h.subscriptionsAction)
+	api.GET("/subscriptions/export", reports, h.exportSubscriptions)
+	api.POST("/subscriptions/import", write, h.requireAllUsers, h.importSubscriptions)
+	api.GET("/subscriptions/trash", read, h.requireAllUsers, h.listTrashSubscriptions)
+	api.GET("/subscriptions/:id", read, h.readSubscription)
+	api.PATCH("/subscriptions/:id", write, h.requirePermission(auth.PermissionUpdateSubscriptions), h.updateSubscription)
+	api.DELETE("/subscriptions/:id", write, h.requirePermission(auth.PermissionDeleteSubscriptions), h.deleteSubscription)
+	api.POST("/subscriptions/:id/restore", write, h.requireAllUsers, h.restoreSubscription)
+
+	api.GET("/subscriptions/:id/history", read, h.listSubscriptionHistory)
+	api.GET("/subscriptions/:id/versions/:version", read, h.readSubscriptionVersion)
+
+	api.GET("/subscriptions/:id/prices", read, h.listSubscriptionPrices)
+	api.POST("/subscriptions/:id/prices", write, h.requirePermission(auth.PermissionUpdateSubscriptions), h.createSubscriptionPrice)
+
+	api.GET("/sum-subscriptions-price", reports, h.requirePermission(auth.PermissionSumSubscriptions), h.sumSubscriptionsPrice)
+
+	api.GET("/api-keys", h.requireAuthentication, admin, h.listAPIKeys)
+	api.POST("/api-keys", h.requireAuthentication, admin, h.createAPIKey)
+	api.DELETE("/api-keys/:id", h.requireAuthentication, admin, h.revokeAPIKey)
 
 	return mux
 }
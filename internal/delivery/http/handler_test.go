@@ -0,0 +1,156 @@
+package http
+
+import (
+	"context"
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository/memory"
+	"eff-subscriptions/internal/service"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTokenSecret signs the tokens accepted by newTestAuthenticator.
+const testTokenSecret = "test-secret"
+
+// testServer the API routes backed by in-memory storages.
+type testServer struct {
+	router        *gin.Engine
+	subscriptions *service.SubscriptionService
+	apiKeys       *service.APIKeyService
+}
+
+// newTestServer returns the routes of a handler. Authentication is disabled if authenticator is nil and
+// requests are not rate limited if rateLimitService is nil.
+func newTestServer(t *testing.T, authenticator *auth.Authenticator, rateLimitService *service.RateLimitService) *testServer {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.DiscardHandler)
+
+	subscriptions := service.NewSubscriptionService(log, memory.NewSubscriptionRepository(memory.NewExchangeRateRepository()))
+	idempotency := service.NewIdempotencyService(log, memory.NewIdempotencyKeyRepository(), time.Hour, time.Minute)
+	apiKeys := service.NewAPIKeyService(log, memory.NewAPIKeyRepository())
+
+	handler := NewHandler(log, subscriptions, idempotency, apiKeys, rateLimitService, authenticator, []byte("cursor-secret"), time.Minute)
+
+	return &testServer{router: handler.InitRoutes(), subscriptions: subscriptions, apiKeys: apiKeys}
+}
+
+// newTestAuthenticator returns an authenticator accepting HS256 tokens signed with testTokenSecret.
+func newTestAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+
+	authenticator, err := auth.NewAuthenticator(config.AuthConfig{
+		Enabled:      true,
+		HMACSecret:   testTokenSecret,
+		AdminRole:    "admin",
+		DefaultRoles: []string{"editor", "finance"},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	return authenticator
+}
+
+// userToken returns a token issued to the user with the roles.
+func userToken(t *testing.T, userID uuid.UUID, roles ...string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   userID.String(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}).SignedString([]byte(testTokenSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+// do serves the request. The token is sent as a bearer token unless it is empty, header holds pairs
+// of additional header names and values.
+func (s *testServer) do(method, target, token, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+
+	return w
+}
+
+// insert stores a subscription of the user.
+func (s *testServer) insert(t *testing.T, serviceName string, price int, userID uuid.UUID) *models.Subscription {
+	t.Helper()
+
+	subscription := &models.Subscription{
+		ServiceName:   serviceName,
+		Price:         &price,
+		Currency:      "RUB",
+		BillingPeriod: models.BillingPeriodMonthly,
+		UserID:        userID,
+		StartDate:     models.CustomDate(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	err := s.subscriptions.Insert(context.Background(), subscription)
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	return subscription
+}
+
+func assertStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	if w.Code != want {
+		t.Fatalf("status: got %d, want %d, body %s", w.Code, want, w.Body.String())
+	}
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+
+	err := json.Unmarshal(w.Body.Bytes(), v)
+	if err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+}
+
+// listIDs returns the ids of the subscriptions listed in the response.
+func listIDs(t *testing.T, w *httptest.ResponseRecorder) []int {
+	t.Helper()
+
+	assertStatus(t, w, http.StatusOK)
+
+	var response models.SubscriptionsListResponse
+	decodeBody(t, w, &response)
+
+	ids := make([]int, len(response.Subscription))
+	for i, subscription := range response.Subscription {
+		ids[i] = subscription.ID
+	}
+
+	return ids
+}
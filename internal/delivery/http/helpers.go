@@ -20,6 +20,15 @@ func readIDParam(c *gin.Context) (int, error) {
 	return id, nil
 }
 
+func readVersionParam(c *gin.Context) (int, error) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version parameter")
+	}
+
+	return version, nil
+}
+
 func readString(c *gin.Context, key string, defaultValue string) string {
 	s := c.Query(key)
 
@@ -80,3 +89,49 @@ func readUUID(c *gin.Context, key string, defaultValue uuid.UUID, v *validator.V
 
 	return u
 }
+
+// readList reads a comma-separated list, empty elements are skipped.
+func readList(c *gin.Context, key string) []string {
+	var values []string
+
+	for _, value := range strings.Split(c.Query(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func readUUIDList(c *gin.Context, key string, v *validator.Validator) []uuid.UUID {
+	var ids []uuid.UUID
+
+	for _, value := range readList(c, key) {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			v.AddError(key, "must be a comma-separated list of valid UUIDs")
+			return nil
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// readBool reads an optional boolean, nil means that the parameter is not set.
+func readBool(c *gin.Context, key string, v *validator.Validator) *bool {
+	s := c.Query(key)
+
+	if s == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddError(key, "must be a boolean")
+		return nil
+	}
+
+	return &b
+}
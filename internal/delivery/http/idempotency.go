@@ -0,0 +1,127 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/service"
+	"eff-subscriptions/internal/validator"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// maxIdempotentRequestSize the maximum size in bytes of the body of a request with an Idempotency-Key header.
+const maxIdempotentRequestSize = 1 << 20
+
+// responseRecorder keeps a copy of the response body written by a handler.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes retries of a request with an Idempotency-Key header safe. The successful response of
+// the first request is stored and replayed to retries with the same method, path and body, reusing the key
+// for another request is rejected. Failed requests are not stored, so they can be retried with the same key.
+func (h *Handler) idempotent(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	v := validator.New()
+
+	if models.ValidateIdempotencyKey(v, key); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	// Keys sent by different callers must not collide.
+	if principal := auth.FromContext(c.Request.Context()); principal != nil {
+		key = principal.Subject() + ":" + key
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentRequestSize))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			h.contentTooLargeResponse(c, maxBytesError.Limit)
+			return
+		}
+		h.badRequestResponse(c, err)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+
+	stored, err := h.idempotencyService.Begin(c.Request.Context(), key, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+			h.errorResponse(c, http.StatusUnprocessableEntity, "the idempotency key has already been used for a different request")
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			h.errorResponse(c, http.StatusConflict, "a request with the same idempotency key is being processed, please retry later")
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	if stored != nil {
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(stored.Status, gin.MIMEJSON+"; charset=utf-8", stored.Response)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// The key is released if a handler panics, otherwise retries would be rejected until the lock times out.
+	panicked := true
+	defer func() {
+		c.Writer = recorder.ResponseWriter
+		h.finishIdempotent(c, key, recorder, panicked)
+	}()
+
+	c.Next()
+
+	panicked = false
+}
+
+// finishIdempotent stores the successful response of the request reserved by the key and releases the key
+// of a failed request.
+func (h *Handler) finishIdempotent(c *gin.Context, key string, recorder *responseRecorder, panicked bool) {
+	// The outcome has to be recorded even if the client has gone away.
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	var err error
+
+	status := recorder.Status()
+	if !panicked && status >= http.StatusOK && status < http.StatusMultipleChoices {
+		err = h.idempotencyService.Complete(ctx, key, status, recorder.body.Bytes())
+	} else {
+		err = h.idempotencyService.Release(ctx, key)
+	}
+	if err != nil {
+		h.logError(c, err)
+	}
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"cmp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// requestIDKey the key of the request id in the gin context.
+const requestIDKey = "request_id"
+
+// problemDetails error response in the RFC 9457 format, returned to clients which accept application/problem+json
+// @Description error described by its HTTP status, with the invalid fields of the request if any
+type problemDetails struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance"`
+	RequestID string         `json:"request_id"`
+	Errors    []fieldProblem `json:"errors,omitempty"`
+}
+
+// fieldProblem validation error of a request field
+// @Description validation error of a request field
+type fieldProblem struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// acceptsProblem reports whether the client prefers application/problem+json to application/json.
+// Clients which accept any type keep getting the errorResponse format.
+func acceptsProblem(c *gin.Context) bool {
+	return c.NegotiateFormat(gin.MIMEJSON, mimeProblemJSON) == mimeProblemJSON
+}
+
+// newProblem describes an error response. The message is either the detail of the problem
+// or a map of field validation errors.
+func newProblem(c *gin.Context, status int, message any) problemDetails {
+	problem := problemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Instance:  c.Request.URL.Path,
+		RequestID: requestID(c),
+	}
+
+	switch message := message.(type) {
+	case string:
+		problem.Detail = message
+	case map[string]string:
+		problem.Detail = "the request contains invalid fields"
+
+		for field, detail := range message {
+			problem.Errors = append(problem.Errors, fieldProblem{Field: field, Detail: detail})
+		}
+
+		slices.SortFunc(problem.Errors, func(a, b fieldProblem) int {
+			return cmp.Compare(a.Field, b.Field)
+		})
+	}
+
+	return problem
+}
+
+// requestID returns the id of the request: the X-Request-ID header sent by the client
+// if it is a short printable string, a random UUID otherwise.
+func requestID(c *gin.Context) string {
+	if id := c.GetString(requestIDKey); id != "" {
+		return id
+	}
+
+	id := c.GetHeader("X-Request-ID")
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+
+	c.Set(requestIDKey, id)
+
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for _, r := range id {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+
+	return true
+}
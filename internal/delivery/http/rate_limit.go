@@ -0,0 +1,71 @@
+package http
+
+import (
+	"eff-subscriptions/internal/auth"
+	"eff-subscriptions/internal/domain/models"
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+	"time"
+)
+
+// rateLimit limits requests of each client, identified by the API key, the user or the IP address. The state
+// of the bucket is reported in RateLimit-* headers, exhausted clients get 429 with Retry-After.
+// Requests are let through if the buckets cannot be reached.
+func (h *Handler) rateLimit(c *gin.Context) {
+	if h.rateLimitService == nil {
+		return
+	}
+
+	client := "ip:" + c.ClientIP()
+	if principal := auth.FromContext(c.Request.Context()); principal != nil {
+		client = principal.Subject()
+	}
+
+	limit, result, err := h.rateLimitService.Allow(c.Request.Context(), client, c.Request.Method+" "+c.FullPath())
+	if err != nil {
+		h.logError(c, err)
+		return
+	}
+
+	setRateLimitHeaders(c, limit, result)
+
+	if !result.Allowed {
+		h.rateLimitExceededResponse(c)
+	}
+}
+
+// rateLimitIP limits requests of each IP address before the credentials are checked, so that requests
+// with missing or invalid credentials are limited too. Allowed requests are reported by rateLimit.
+func (h *Handler) rateLimitIP(c *gin.Context) {
+	if h.rateLimitService == nil {
+		return
+	}
+
+	limit, result, err := h.rateLimitService.AllowIP(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		h.logError(c, err)
+		return
+	}
+
+	if !result.Allowed {
+		setRateLimitHeaders(c, limit, result)
+		h.rateLimitExceededResponse(c)
+	}
+}
+
+// setRateLimitHeaders reports the state of the bucket, Retry-After is set once it is exhausted.
+func setRateLimitHeaders(c *gin.Context, limit models.RateLimit, result models.RateLimitResult) {
+	c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(int(result.Tokens)))
+	c.Header("RateLimit-Reset", seconds(limit.TimeUntil(result.Tokens, float64(limit.Burst))))
+
+	if !result.Allowed {
+		c.Header("Retry-After", seconds(limit.TimeUntil(result.Tokens, 1)))
+	}
+}
+
+// seconds formats the duration as whole seconds rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
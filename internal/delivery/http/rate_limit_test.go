@@ -0,0 +1,68 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository/memory"
+	"eff-subscriptions/internal/service"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func newTestRateLimitService(defaultLimit models.RateLimit, routeLimits map[string]models.RateLimit, ipLimit models.RateLimit) *service.RateLimitService {
+	return service.NewRateLimitService(slog.New(slog.DiscardHandler), memory.NewRateLimitRepository(), defaultLimit, routeLimits, ipLimit)
+}
+
+func TestRateLimit(t *testing.T) {
+	// A token a minute, so that the bucket does not refill while the test runs.
+	limit := models.RateLimit{Rate: 1.0 / 60, Burst: 2}
+	routeLimits := map[string]models.RateLimit{"GET /v1/sum-subscriptions-price": {Rate: 1.0 / 60, Burst: 1}}
+
+	s := newTestServer(t, newTestAuthenticator(t), newTestRateLimitService(limit, routeLimits, models.RateLimit{Rate: 100, Burst: 100}))
+	token, other := userToken(t, uuid.New()), userToken(t, uuid.New())
+
+	for i, want := range []string{"1", "0"} {
+		w := s.do(http.MethodGet, "/v1/subscriptions", token, "")
+		assertStatus(t, w, http.StatusOK)
+
+		if got := w.Header().Get("RateLimit-Remaining"); got != want {
+			t.Fatalf("request %d: RateLimit-Remaining %q, want %q", i, got, want)
+		}
+	}
+
+	w := s.do(http.MethodGet, "/v1/subscriptions", token, "")
+	assertStatus(t, w, http.StatusTooManyRequests)
+
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Fatalf("Retry-After: got %q, want between 1 and 60 seconds", w.Header().Get("Retry-After"))
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Fatalf("RateLimit-Limit: got %q, want %q", got, "2")
+	}
+
+	// Routes with a limit of their own and other callers have buckets of their own.
+	assertStatus(t, s.do(http.MethodGet, "/v1/sum-subscriptions-price?start_date=01-2025&end_date=12-2025", token, ""), http.StatusOK)
+	assertStatus(t, s.do(http.MethodGet, "/v1/sum-subscriptions-price?start_date=01-2025&end_date=12-2025", token, ""), http.StatusTooManyRequests)
+	assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions", other, ""), http.StatusOK)
+}
+
+// TestRateLimitIP checks that requests with invalid credentials are limited by the IP address.
+func TestRateLimitIP(t *testing.T) {
+	limit := models.RateLimit{Rate: 100, Burst: 100}
+	ipLimit := models.RateLimit{Rate: 1.0 / 60, Burst: 2}
+
+	s := newTestServer(t, newTestAuthenticator(t), newTestRateLimitService(limit, nil, ipLimit))
+
+	assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions", "invalid", ""), http.StatusUnauthorized)
+	assertStatus(t, s.do(http.MethodGet, "/v1/subscriptions", "", ""), http.StatusUnauthorized)
+
+	w := s.do(http.MethodGet, "/v1/subscriptions", userToken(t, uuid.New()), "")
+	assertStatus(t, w, http.StatusTooManyRequests)
+
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("Retry-After is not set")
+	}
+}
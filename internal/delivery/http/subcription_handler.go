@@ -5,6 +5,7 @@ import (
 	"eff-subscriptions/internal/repository"
 	"eff-subscriptions/internal/validator"
 	"errors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -14,15 +15,26 @@ import (
 
 // createSubscription godoc
 // @Summary Create a new subscription
-// @Description Create a new subscription with the input payload
+// @Description Create a new subscription with the input payload. A request with an Idempotency-Key header is processed once,
+// @Description retries with the same key and body get the original response, the same key with another body is rejected with 422
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
+// @Param Idempotency-Key header string false "unique key of the request, at most 255 printable ASCII characters"
 // @Param input body models.CreateSubscriptionRequest true "Subscription object"
 // @Success 201 {object} models.SubscriptionResponse
+// @Header 201 {string} Idempotent-Replayed "true if the response of an earlier request is replayed"
 // @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 413 {object} errorResponse
 // @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
 // @Router /v1/subscriptions [post]
 func (h *Handler) createSubscription(c *gin.Context) {
 	var input models.CreateSubscriptionRequest
@@ -34,11 +46,30 @@ func (h *Handler) createSubscription(c *gin.Context) {
 	}
 
 	subscription := &models.Subscription{
-		ServiceName: input.ServiceName,
-		Price:       input.Price,
-		UserID:      input.UserID,
-		StartDate:   input.StartDate,
-		EndDate:     input.EndDate,
+		ServiceName:   input.ServiceName,
+		Price:         input.Price,
+		Currency:      input.Currency,
+		BillingPeriod: input.BillingPeriod,
+		UserID:        input.UserID,
+		StartDate:     input.StartDate,
+		EndDate:       input.EndDate,
+	}
+
+	if subscription.Currency == "" {
+		subscription.Currency = models.DefaultCurrency
+	}
+	if subscription.BillingPeriod == "" {
+		subscription.BillingPeriod = models.BillingPeriodMonthly
+	}
+
+	if userID, scoped := scopedUserID(c); scoped {
+		if subscription.UserID == uuid.Nil {
+			subscription.UserID = userID
+		}
+		if subscription.UserID != userID {
+			h.notPermittedResponse(c)
+			return
+		}
 	}
 
 	v := validator.New()
@@ -48,7 +79,7 @@ func (h *Handler) createSubscription(c *gin.Context) {
 		return
 	}
 
-	err = h.subscriptionService.Insert(subscription)
+	err = h.subscriptionService.Insert(c.Request.Context(), subscription)
 	if err != nil {
 		h.serverErrorResponse(c, err)
 		return
@@ -59,15 +90,25 @@ func (h *Handler) createSubscription(c *gin.Context) {
 
 // readSubscription godoc
 // @Summary Get subscription
-// @Description Return subscription by id
+// @Description Return subscription by id. The ETag header holds the version of the subscription, with a matching
+// @Description If-None-Match header 304 Not Modified is returned without a body
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID subscription"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} models.SubscriptionResponse
+// @Success 304 "not modified"
+// @Header 200,304 {string} ETag "version of the subscription"
 // @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
 // @Router /v1/subscriptions/{id} [get]
 func (h *Handler) readSubscription(c *gin.Context) {
 	id, err := readIDParam(c)
@@ -76,7 +117,7 @@ func (h *Handler) readSubscription(c *gin.Context) {
 		return
 	}
 
-	subscription, err := h.subscriptionService.Get(id)
+	subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrRecordNotFound):
@@ -87,23 +128,53 @@ func (h *Handler) readSubscription(c *gin.Context) {
 		return
 	}
 
+	if !ownsSubscription(c, subscription) {
+		h.notFoundResponse(c)
+		return
+	}
+
+	etag := subscriptionETag(subscription)
+	c.Header("ETag", etag)
+
+	if header := c.GetHeader("If-None-Match"); header != "" && matchETag(header, etag, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, models.SubscriptionResponse{Subscription: subscription})
 }
 
 // updateSubscription godoc
 // @Summary Update subscription
-// @Description Update subscription by id
+// @Description Update subscription by id. With an If-Match header the subscription is updated only if its ETag matches,
+// @Description otherwise 412 Precondition Failed is returned. The X-Expected-Version header is still supported and
+// @Description fails with 409 Conflict. A JSON body changes only the fields which are not null. With the
+// @Description application/merge-patch+json (RFC 7396) or application/json-patch+json (RFC 6902) content types
+// @Description null values and removed members clear the fields, so end_date can be removed. A failed JSON Patch
+// @Description test operation is reported with 409 Conflict
 // @Tags subscriptions
 // @Accept  json
+// @Accept  application/merge-patch+json
+// @Accept  application/json-patch+json
 // @Produce  json
 // @Param id path int true "ID subscription"
+// @Param If-Match header string false "ETag of the subscription to update"
+// @Param X-Expected-Version header int false "version of the subscription to update"
 // @Param input body models.UpdateSubscriptionRequest true "New data"
 // @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "new version of the subscription"
 // @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 404 {object} errorResponse
 // @Failure 409 {object} errorResponse
+// @Failure 412 {object} errorResponse
 // @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
 // @Router /v1/subscriptions/{id} [patch]
 func (h *Handler) updateSubscription(c *gin.Context) {
 	id, err := readIDParam(c)
@@ -112,7 +183,7 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 		return
 	}
 
-	subscription, err := h.subscriptionService.Get(id)
+	subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrRecordNotFound):
@@ -123,6 +194,15 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 		return
 	}
 
+	if !ownsSubscription(c, subscription) {
+		h.notFoundResponse(c)
+		return
+	}
+
+	if !h.checkIfMatch(c, subscription) {
+		return
+	}
+
 	if c.GetHeader("X-Expected-Version") != "" {
 		if strconv.Itoa(subscription.Version) != c.GetHeader("X-Expected-Version") {
 			h.editConflictResponse(c)
@@ -130,20 +210,79 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 		}
 	}
 
-	var input models.UpdateSubscriptionRequest
+	switch c.ContentType() {
+	case mimeMergePatch, mimeJSONPatch:
+		patch, err := c.GetRawData()
+		if err != nil {
+			h.badRequestResponse(c, err)
+			return
+		}
+
+		err = patchSubscription(subscription, c.ContentType(), patch)
+		if err != nil {
+			switch {
+			case errors.Is(err, jsonpatch.ErrTestFailed):
+				h.editConflictResponse(c)
+			default:
+				h.badRequestResponse(c, err)
+			}
+			return
+		}
+	default:
+		var input models.UpdateSubscriptionRequest
+
+		err = c.BindJSON(&input)
+		if err != nil {
+			h.badRequestResponse(c, err)
+			return
+		}
+
+		applySubscriptionUpdate(subscription, &input)
+	}
+
+	if !ownsSubscription(c, subscription) {
+		h.notPermittedResponse(c)
+		return
+	}
+
+	v := validator.New()
+
+	if models.ValidateSubscription(v, subscription); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
 
-	err = c.BindJSON(&input)
+	err = h.subscriptionService.Update(c.Request.Context(), subscription)
 	if err != nil {
-		h.badRequestResponse(c, err)
+		switch {
+		case errors.Is(err, repository.ErrEditConflict) && c.GetHeader("If-Match") != "":
+			h.preconditionFailedResponse(c)
+		case errors.Is(err, repository.ErrEditConflict):
+			h.editConflictResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
 		return
 	}
 
+	c.Header("ETag", subscriptionETag(subscription))
+	c.JSON(http.StatusOK, models.SubscriptionResponse{Subscription: subscription})
+}
+
+// applySubscriptionUpdate sets the fields of the subscription provided in the update request.
+func applySubscriptionUpdate(subscription *models.Subscription, input *models.UpdateSubscriptionRequest) {
 	if input.ServiceName != nil {
 		subscription.ServiceName = *input.ServiceName
 	}
 	if input.Price != nil {
 		subscription.Price = input.Price
 	}
+	if input.Currency != nil {
+		subscription.Currency = *input.Currency
+	}
+	if input.BillingPeriod != nil {
+		subscription.BillingPeriod = *input.BillingPeriod
+	}
 	if input.UserID != nil {
 		subscription.UserID = *input.UserID
 	}
@@ -153,17 +292,70 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 	if input.EndDate != nil {
 		subscription.EndDate = input.EndDate
 	}
+}
 
-	v := validator.New()
-
-	if models.ValidateSubscription(v, subscription); !v.Valid() {
-		h.failedValidationResponse(c, v.Errors)
+// deleteSubscription godoc
+// @Summary Delete subscription
+// @Description Move subscription to the trash by id. It can be restored until it is purged. With an If-Match header
+// @Description the subscription is deleted only if its ETag matches, otherwise 412 Precondition Failed is returned
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID subscription"
+// @Param If-Match header string false "ETag of the subscription to delete"
+// @Success 200 {object} models.DataResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 412 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id} [delete]
+func (h *Handler) deleteSubscription(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
 		return
 	}
 
-	err = h.subscriptionService.Update(subscription)
+	// The version is checked once more by the delete itself, so a change made meanwhile is not lost.
+	version := 0
+
+	if _, scoped := scopedUserID(c); scoped || c.GetHeader("If-Match") != "" {
+		subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrRecordNotFound):
+				h.notFoundResponse(c)
+			default:
+				h.serverErrorResponse(c, err)
+			}
+			return
+		}
+
+		if !ownsSubscription(c, subscription) {
+			h.notFoundResponse(c)
+			return
+		}
+
+		if !h.checkIfMatch(c, subscription) {
+			return
+		}
+
+		version = subscription.Version
+	}
+
+	err = h.subscriptionService.Delete(c.Request.Context(), id, version)
 	if err != nil {
 		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		case errors.Is(err, repository.ErrEditConflict) && c.GetHeader("If-Match") != "":
+			h.preconditionFailedResponse(c)
 		case errors.Is(err, repository.ErrEditConflict):
 			h.editConflictResponse(c)
 		default:
@@ -172,29 +364,82 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.SubscriptionResponse{Subscription: subscription})
+	c.JSON(http.StatusOK, models.DataResponse{Data: "subscription successfully deleted"})
 }
 
-// deleteSubscription godoc
-// @Summary Delete subscription
-// @Description Delete subscription by id
-// @Tags subscriptions
+// listTrashSubscriptions godoc
+// @Summary Deleted subscriptions list
+// @Description Return deleted subscriptions that have not been purged yet with pagination
+// @Tags trash
+// @Accept  json
+// @Produce  json
+// @Param page query int false "page number"
+// @Param page_size query int false "items limit on page"
+// @Param sort query string false "comma-separated sort columns, a leading hyphen sorts in descending order: id, service_name, deleted_at" default(-deleted_at)
+// @Success 200 {object} models.SubscriptionsListResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/trash [get]
+func (h *Handler) listTrashSubscriptions(c *gin.Context) {
+	var input struct {
+		models.Filters
+	}
+
+	v := validator.New()
+
+	input.Filters.Page = readInt(c, "page", 1, v)
+	input.Filters.PageSize = readInt(c, "page_size", 20, v)
+
+	input.Filters.Sort = readString(c, "sort", "-deleted_at")
+	input.Filters.SortSafelist = []string{"id", "service_name", "deleted_at", "-id", "-service_name", "-deleted_at"}
+
+	if models.ValidateFilters(v, input.Filters); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	subscriptions, metadata, err := h.subscriptionService.GetTrash(c.Request.Context(), input.Filters)
+	if err != nil {
+		h.serverErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubscriptionsListResponse{Subscription: subscriptions, Metadata: metadata})
+}
+
+// restoreSubscription godoc
+// @Summary Restore subscription
+// @Description Take deleted subscription out of the trash by id
+// @Tags trash
 // @Accept  json
 // @Produce  json
 // @Param id path int true "ID subscription"
-// @Success 200 {object} models.DataResponse
+// @Success 200 {object} models.SubscriptionResponse
+// @Header 200 {string} ETag "version of the subscription"
 // @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
-// @Router /v1/subscriptions/{id} [delete]
-func (h *Handler) deleteSubscription(c *gin.Context) {
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id}/restore [post]
+func (h *Handler) restoreSubscription(c *gin.Context) {
 	id, err := readIDParam(c)
 	if err != nil {
 		h.badRequestResponse(c, err)
 		return
 	}
 
-	err = h.subscriptionService.Delete(id)
+	subscription, err := h.subscriptionService.Restore(c.Request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrRecordNotFound):
@@ -205,7 +450,8 @@ func (h *Handler) deleteSubscription(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.DataResponse{Data: "subscription successfully deleted"})
+	c.Header("ETag", subscriptionETag(subscription))
+	c.JSON(http.StatusOK, models.SubscriptionResponse{Subscription: subscription})
 }
 
 // listSubscriptions godoc
@@ -214,58 +460,121 @@ func (h *Handler) deleteSubscription(c *gin.Context) {
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
-// @Param service_name query string false "service name"
+// @Param service_name query string false "comma-separated service names, matches any of them by words"
 // @Param price query int false "price"
-// @Param user_id query string false "user id"
+// @Param price_min query int false "minimum price"
+// @Param price_max query int false "maximum price"
+// @Param user_id query string false "comma-separated user ids"
 // @Param start_date query string false "start date"
+// @Param start_from query string false "started in this month or later, MM-YYYY"
+// @Param start_to query string false "started in this month or earlier, MM-YYYY"
+// @Param end_from query string false "ends in this month or later, MM-YYYY"
+// @Param end_to query string false "ends in this month or earlier, MM-YYYY"
+// @Param active_on query string false "active in this month, MM-YYYY"
+// @Param has_end_date query bool false "has or has not an end date"
+// @Param currency query string false "convert prices to this ISO 4217 currency at the exchange rate effective in the billing month: the current month, the first month of a future subscription or the last month of an ended one"
 // @Param page query int false "page number"
 // @Param page_size query int false "items limit on page"
-// @Param sort query string false "sort field" Enums(id, service_name, price, start_date, -id, -service_name, -year, -price, -start_date) default(id)
+// @Param sort query string false "comma-separated sort columns, a leading hyphen sorts in descending order: id, service_name, price, start_date" default(id)
+// @Param after query string false "return the page after this cursor, taken from next_cursor"
+// @Param before query string false "return the page before this cursor, taken from prev_cursor"
 // @Success 200 {object} models.SubscriptionsListResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
 // @Router /v1/subscriptions [get]
 func (h *Handler) listSubscriptions(c *gin.Context) {
 	var input struct {
-		ServiceName string            `json:"service_name"`
-		Price       int               `json:"price"`
-		UserID      uuid.UUID         `json:"user_id"`
-		StartDate   models.CustomDate `json:"start_date"`
+		models.SubscriptionsFilter
+		Currency string `json:"currency"`
 		models.Filters
 	}
 
 	v := validator.New()
 
-	input.ServiceName = readString(c, "service_name", "")
-	input.Price = readInt(c, "price", -1, v)
-	input.UserID = readUUID(c, "user_id", uuid.Nil, v)
-	input.StartDate = readDate(c, "start_date", models.CustomDate(time.Time{}), v)
+	input.SubscriptionsFilter = readSubscriptionsFilter(c, v)
+	input.Currency = readCurrency(c, v)
 
 	input.Filters.Page = readInt(c, "page", 1, v)
 	input.Filters.PageSize = readInt(c, "page_size", 20, v)
 
 	input.Filters.Sort = readString(c, "sort", "id")
-	input.Filters.SortSafelist = []string{"id", "service_name", "price", "start_date", "-id", "-service_name", "-year", "-price", "-start_date"}
+	input.Filters.SortSafelist = subscriptionsSortSafelist
+
+	input.Filters.After = h.readCursor(c, "after", v)
+	input.Filters.Before = h.readCursor(c, "before", v)
 
 	if models.ValidateFilters(v, input.Filters); !v.Valid() {
 		h.failedValidationResponse(c, v.Errors)
 		return
 	}
 
-	subscriptions, metadata, err := h.subscriptionService.GetAll(input.ServiceName, input.Price, input.UserID,
-		input.StartDate, input.Filters)
+	if !scopeSubscriptionsFilter(c, &input.SubscriptionsFilter) {
+		h.notPermittedResponse(c)
+		return
+	}
+
+	subscriptions, metadata, err := h.subscriptionService.GetAll(c.Request.Context(), input.SubscriptionsFilter, input.Currency, input.Filters)
 	if err != nil {
-		h.serverErrorResponse(c, err)
+		switch {
+		case errors.Is(err, repository.ErrExchangeRateNotFound):
+			v.AddError("currency", "no exchange rate available for some of the subscriptions in their billing month")
+			h.failedValidationResponse(c, v.Errors)
+		default:
+			h.serverErrorResponse(c, err)
+		}
 		return
 	}
 
+	h.setCursors(&metadata, input.Filters, subscriptions)
+
 	c.JSON(http.StatusOK, models.SubscriptionsListResponse{Subscription: subscriptions, Metadata: metadata})
+}
+
+// subscriptionsSortSafelist sort values accepted by the subscriptions list and export.
+var subscriptionsSortSafelist = []string{"id", "service_name", "price", "start_date", "-id", "-service_name", "-price", "-start_date"}
+
+// readSubscriptionsFilter reads and validates the filter parameters of the subscriptions list and export.
+func readSubscriptionsFilter(c *gin.Context, v *validator.Validator) models.SubscriptionsFilter {
+	filter := models.SubscriptionsFilter{
+		ServiceNames: readList(c, "service_name"),
+		UserIDs:      readUUIDList(c, "user_id", v),
+		Price:        readInt(c, "price", -1, v),
+		PriceMin:     readInt(c, "price_min", -1, v),
+		PriceMax:     readInt(c, "price_max", -1, v),
+		StartDate:    readDate(c, "start_date", models.CustomDate(time.Time{}), v),
+		StartFrom:    readDate(c, "start_from", models.CustomDate(time.Time{}), v),
+		StartTo:      readDate(c, "start_to", models.CustomDate(time.Time{}), v),
+		EndFrom:      readDate(c, "end_from", models.CustomDate(time.Time{}), v),
+		EndTo:        readDate(c, "end_to", models.CustomDate(time.Time{}), v),
+		ActiveOn:     readDate(c, "active_on", models.CustomDate(time.Time{}), v),
+		HasEndDate:   readBool(c, "has_end_date", v),
+	}
+
+	models.ValidateSubscriptionsFilter(v, filter)
+
+	return filter
+}
+
+// readCurrency reads and validates the optional currency prices are converted to.
+func readCurrency(c *gin.Context, v *validator.Validator) string {
+	currency := readString(c, "currency", "")
 
+	if currency != "" {
+		models.ValidateCurrency(v, "currency", currency)
+	}
+
+	return currency
 }
 
 // sumSubscriptionsPrice godoc
 // @Summary Sums up subscriptions prices
-// @Description Sums up subscription prices over a date range
+// @Description Charges every subscription for each month it is active within a date range and returns the total with per-month breakdown
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
@@ -273,9 +582,16 @@ func (h *Handler) listSubscriptions(c *gin.Context) {
 // @Param user_id query string false "user id"
 // @Param start_date query string true "start date"
 // @Param end_date query string true "end date"
-// @Success 200 {object} models.DataResponse
+// @Param currency query string false "ISO 4217 currency of the result, each month is converted at the exchange rate effective on its first day" default(RUB)
+// @Success 200 {object} models.DataResponse{data=models.SubscriptionsSum}
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
 // @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
 // @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
 // @Router /v1/sum-subscriptions-price [get]
 func (h *Handler) sumSubscriptionsPrice(c *gin.Context) {
 	var input struct {
@@ -283,6 +599,7 @@ func (h *Handler) sumSubscriptionsPrice(c *gin.Context) {
 		ServiceName string            `json:"service_name"`
 		StartDate   models.CustomDate `json:"start_date"`
 		EndDate     models.CustomDate `json:"end_date"`
+		Currency    string            `json:"currency"`
 	}
 
 	v := validator.New()
@@ -290,9 +607,13 @@ func (h *Handler) sumSubscriptionsPrice(c *gin.Context) {
 	input.UserID = readUUID(c, "user_id", uuid.Nil, v)
 	input.ServiceName = readString(c, "service_name", "")
 	input.StartDate = readDate(c, "start_date", models.CustomDate(time.Time{}), v)
+	now := time.Now().UTC()
 	input.EndDate = readDate(c, "end_date",
-		models.CustomDate(time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)),
+		models.CustomDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)),
 		v)
+	input.Currency = readString(c, "currency", models.DefaultCurrency)
+
+	models.ValidateCurrency(v, "currency", input.Currency)
 
 	if input.StartDate.Time().After(input.EndDate.Time()) {
 		v.AddError("start_date", "must be before end_date")
@@ -303,9 +624,23 @@ func (h *Handler) sumSubscriptionsPrice(c *gin.Context) {
 		return
 	}
 
-	sum, err := h.subscriptionService.GetSubscriptionsSum(input.UserID, input.ServiceName, input.StartDate, input.EndDate)
+	if userID, scoped := scopedUserID(c); scoped {
+		if input.UserID != uuid.Nil && input.UserID != userID {
+			h.notPermittedResponse(c)
+			return
+		}
+		input.UserID = userID
+	}
+
+	sum, err := h.subscriptionService.GetSubscriptionsSum(c.Request.Context(), input.UserID, input.ServiceName, input.StartDate, input.EndDate, input.Currency)
 	if err != nil {
-		h.serverErrorResponse(c, err)
+		switch {
+		case errors.Is(err, repository.ErrExchangeRateNotFound):
+			v.AddError("currency", "no exchange rate available for some of the subscriptions in the requested period")
+			h.failedValidationResponse(c, v.Errors)
+		default:
+			h.serverErrorResponse(c, err)
+		}
 		return
 	}
 
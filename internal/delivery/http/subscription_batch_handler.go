@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// subscriptionsAction dispatches custom methods of the subscriptions collection, such as /v1/subscriptions:batch.
+// The router treats a colon as the start of a path parameter, so the method name is read as one.
+func (h *Handler) subscriptionsAction(c *gin.Context) {
+	switch c.Param("action") {
+	case ":batch":
+		h.batchSubscriptions(c)
+	default:
+		h.notFoundResponse(c)
+	}
+}
+
+// batchSubscriptions godoc
+// @Summary Bulk create, update and delete subscriptions
+// @Description Apply up to 100 create, update and delete operations in one transaction. By default the batch is applied
+// @Description all or nothing: if an operation fails, the response has its status and no operation is applied.
+// @Description With partial=true the failed operations are skipped, the others are applied and the response status is 207
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param partial query bool false "apply the operations which succeed even if others fail" default(false)
+// @Param input body models.BatchRequest true "Operations"
+// @Success 200 {object} models.BatchResponse
+// @Success 207 {object} models.BatchResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} models.BatchResponse
+// @Failure 409 {object} models.BatchResponse
+// @Failure 422 {object} models.BatchResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions:batch [post]
+func (h *Handler) batchSubscriptions(c *gin.Context) {
+	var input models.BatchRequest
+
+	err := c.BindJSON(&input)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	v := validator.New()
+
+	partial := false
+	if b := readBool(c, "partial", v); b != nil {
+		partial = *b
+	}
+	v.Check(len(input.Operations) > 0, "operations", "must contain at least one operation")
+	v.Check(len(input.Operations) <= models.MaxBatchOperations, "operations", "must not contain more than "+strconv.Itoa(models.MaxBatchOperations)+" operations")
+
+	if !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	results := make([]models.BatchResult, len(input.Operations))
+	operations := make([]*models.BatchOperation, len(input.Operations))
+	var batch []*models.BatchOperation
+
+	for i, item := range input.Operations {
+		results[i] = models.BatchResult{Index: i, Op: item.Op}
+
+		subscription, err := h.prepareBatchOperation(c.Request.Context(), item, &results[i])
+		if err != nil {
+			h.serverErrorResponse(c, err)
+			return
+		}
+
+		if subscription != nil {
+			operations[i] = &models.BatchOperation{Op: item.Op, Subscription: subscription}
+			batch = append(batch, operations[i])
+		}
+	}
+
+	// Without partial a batch with an invalid operation is not sent to the storage at all.
+	if len(batch) > 0 && (len(batch) == len(operations) || partial) {
+		err = h.subscriptionService.Batch(c.Request.Context(), batch, partial)
+		if err != nil {
+			h.serverErrorResponse(c, err)
+			return
+		}
+	}
+
+	failed := len(batch) < len(operations)
+
+	for i, operation := range operations {
+		switch {
+		case operation == nil:
+		case errors.Is(operation.Err, repository.ErrRecordNotFound):
+			results[i].Status, results[i].Error = http.StatusNotFound, "the requested resource could not be found"
+			failed = true
+		case errors.Is(operation.Err, repository.ErrEditConflict):
+			results[i].Status, results[i].Error = http.StatusConflict, "unable to update the record due to an edit conflict, please try again"
+			failed = true
+		case operation.Op == models.BatchOperationCreate:
+			results[i].Status, results[i].Subscription = http.StatusCreated, operation.Subscription
+		case operation.Op == models.BatchOperationUpdate:
+			results[i].Status, results[i].Subscription = http.StatusOK, operation.Subscription
+		default:
+			results[i].Status = http.StatusOK
+		}
+	}
+
+	status := http.StatusOK
+
+	switch {
+	case failed && !partial:
+		for i := range results {
+			if status == http.StatusOK && results[i].Error != nil {
+				status = results[i].Status
+			}
+			if results[i].Error == nil {
+				results[i].Status, results[i].Subscription = http.StatusFailedDependency, nil
+				results[i].Error = "not applied because another operation of the batch failed"
+			}
+		}
+	case failed:
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, models.BatchResponse{Results: results})
+}
+
+// prepareBatchOperation validates the operation of a batch and returns the subscription to store.
+// An update is applied to the current state of the subscription like a PATCH request. If the operation
+// is invalid its status and error are set in result and nil is returned.
+func (h *Handler) prepareBatchOperation(ctx context.Context, item models.BatchOperationRequest, result *models.BatchResult) (*models.Subscription, error) {
+	v := validator.New()
+
+	switch item.Op {
+	case models.BatchOperationCreate:
+		v.Check(item.Subscription != nil, "subscription", "must be provided")
+	case models.BatchOperationUpdate:
+		v.Check(item.ID > 0, "id", "must be a positive integer")
+		v.Check(item.Subscription != nil, "subscription", "must be provided")
+	case models.BatchOperationDelete:
+		v.Check(item.ID > 0, "id", "must be a positive integer")
+	default:
+		v.AddError("op", "must be one of create, update, delete")
+	}
+
+	if !v.Valid() {
+		result.Status, result.Error = http.StatusUnprocessableEntity, v.Errors
+		return nil, nil
+	}
+
+	var subscription *models.Subscription
+
+	switch item.Op {
+	case models.BatchOperationCreate:
+		subscription = &models.Subscription{Currency: models.DefaultCurrency, BillingPeriod: models.BillingPeriodMonthly}
+	case models.BatchOperationUpdate:
+		var err error
+
+		subscription, err = h.subscriptionService.Get(ctx, item.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrRecordNotFound):
+				result.Status, result.Error = http.StatusNotFound, "the requested resource could not be found"
+				return nil, nil
+			default:
+				return nil, err
+			}
+		}
+
+		if item.Version != nil && *item.Version != subscription.Version {
+			result.Status, result.Error = http.StatusConflict, "unable to update the record due to an edit conflict, please try again"
+			return nil, nil
+		}
+	default:
+		return &models.Subscription{ID: item.ID}, nil
+	}
+
+	applySubscriptionUpdate(subscription, item.Subscription)
+
+	if models.ValidateSubscription(v, subscription); !v.Valid() {
+		result.Status, result.Error = http.StatusUnprocessableEntity, v.Errors
+		return nil, nil
+	}
+
+	return subscription, nil
+}
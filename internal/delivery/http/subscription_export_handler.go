@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mime"
+	"net/http"
+	"time"
+)
+
+var exportContentTypes = map[string]string{
+	models.ExportFormatCSV:   "text/csv; charset=utf-8",
+	models.ExportFormatJSONL: "application/x-ndjson",
+	models.ExportFormatXLSX:  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportSubscriptions godoc
+// @Summary Export subscriptions
+// @Description Download all subscriptions matching the filters of the subscriptions list as a CSV, JSON Lines or XLSX file.
+// @Description The export is not paginated, rows are streamed as they are read from the database
+// @Tags subscriptions
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "file format" Enums(csv, jsonl, xlsx) default(csv)
+// @Param service_name query string false "comma-separated service names, matches any of them by words"
+// @Param price query int false "price"
+// @Param price_min query int false "minimum price"
+// @Param price_max query int false "maximum price"
+// @Param user_id query string false "comma-separated user ids"
+// @Param start_date query string false "start date"
+// @Param start_from query string false "started in this month or later, MM-YYYY"
+// @Param start_to query string false "started in this month or earlier, MM-YYYY"
+// @Param end_from query string false "ends in this month or later, MM-YYYY"
+// @Param end_to query string false "ends in this month or earlier, MM-YYYY"
+// @Param active_on query string false "active in this month, MM-YYYY"
+// @Param has_end_date query bool false "has or has not an end date"
+// @Param currency query string false "add prices converted to this ISO 4217 currency at the exchange rate effective in the billing month, like the list"
+// @Param sort query string false "comma-separated sort columns, a leading hyphen sorts in descending order: id, service_name, price, start_date" default(id)
+// @Success 200 {file} file
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/export [get]
+func (h *Handler) exportSubscriptions(c *gin.Context) {
+	v := validator.New()
+
+	format := readString(c, "format", models.ExportFormatCSV)
+	v.Check(validator.PermittedValue(format, models.ExportFormats...), "format", "must be one of csv, jsonl, xlsx")
+
+	filter := readSubscriptionsFilter(c, v)
+	currency := readCurrency(c, v)
+
+	filters := models.Filters{
+		Sort:         readString(c, "sort", "id"),
+		SortSafelist: subscriptionsSortSafelist,
+	}
+
+	if models.ValidateSort(v, filters); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	if !scopeSubscriptionsFilter(c, &filter) {
+		h.notPermittedResponse(c)
+		return
+	}
+
+	// The export is written while the subscriptions are read, the server write timeout would cut it off.
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(h.exportTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.serverErrorResponse(c, err)
+		return
+	}
+
+	filename := "subscriptions-" + time.Now().UTC().Format(time.DateOnly) + "." + format
+
+	c.Header("Content-Type", exportContentTypes[format])
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	err = h.subscriptionService.Export(c.Request.Context(), c.Writer, format, filter, currency, filters)
+	if err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+
+			switch {
+			case errors.Is(err, repository.ErrExchangeRateNotFound):
+				v.AddError("currency", "no exchange rate available for some of the subscriptions in their billing month")
+				h.failedValidationResponse(c, v.Errors)
+			default:
+				h.serverErrorResponse(c, err)
+			}
+			return
+		}
+
+		// The status has already been sent, the client sees a truncated file.
+		if !errors.Is(err, context.Canceled) {
+			h.logError(c, err)
+		}
+		c.Abort()
+	}
+}
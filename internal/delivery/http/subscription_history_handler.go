@@ -0,0 +1,102 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// listSubscriptionHistory godoc
+// @Summary Subscription change history
+// @Description Return every change of the subscription with author, time, old and new values ordered by version
+// @Tags history
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID subscription"
+// @Success 200 {object} models.SubscriptionHistoryResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id}/history [get]
+func (h *Handler) listSubscriptionHistory(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	if !h.checkSubscriptionOwner(c, id) {
+		return
+	}
+
+	history, err := h.subscriptionService.GetHistory(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubscriptionHistoryResponse{History: history})
+}
+
+// readSubscriptionVersion godoc
+// @Summary Get subscription version
+// @Description Return subscription as it was right after the change with the given version
+// @Tags history
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID subscription"
+// @Param version path int true "subscription version"
+// @Success 200 {object} models.SubscriptionResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id}/versions/{version} [get]
+func (h *Handler) readSubscriptionVersion(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	version, err := readVersionParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	if !h.checkSubscriptionOwner(c, id) {
+		return
+	}
+
+	subscription, err := h.subscriptionService.GetVersion(c.Request.Context(), id, version)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubscriptionResponse{Subscription: subscription})
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/service"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// maxImportSize the maximum size of an imported file in bytes.
+const maxImportSize = 10 << 20
+
+// importSubscriptions godoc
+// @Summary Import subscriptions from CSV
+// @Description Import subscriptions from a CSV file with a header. Columns are matched to the fields service_name, price,
+// @Description currency, billing_period, user_id, start_date and end_date by name, other names are set with
+// @Description columns[<field>]=<column> parameters. Dates use the MM-YYYY format. With dry_run=true the rows are only
+// @Description validated, otherwise the valid rows are imported in one transaction and the invalid ones are reported
+// @Tags subscriptions
+// @Accept  text/csv
+// @Produce  json
+// @Param dry_run query bool false "only validate the file" default(false)
+// @Param delimiter query string false "field delimiter" default(,)
+// @Param columns[service_name] query string false "column with the service name"
+// @Param columns[price] query string false "column with the price"
+// @Param columns[currency] query string false "column with the currency"
+// @Param columns[billing_period] query string false "column with the billing period"
+// @Param columns[user_id] query string false "column with the user id"
+// @Param columns[start_date] query string false "column with the start date"
+// @Param columns[end_date] query string false "column with the end date"
+// @Param file body string true "CSV file"
+// @Success 200 {object} models.ImportReport
+// @Success 201 {object} models.ImportReport
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 413 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/import [post]
+func (h *Handler) importSubscriptions(c *gin.Context) {
+	v := validator.New()
+
+	options := models.ImportOptions{
+		Columns:   c.QueryMap("columns"),
+		Delimiter: readString(c, "delimiter", ","),
+	}
+
+	if dryRun := readBool(c, "dry_run", v); dryRun != nil {
+		options.DryRun = *dryRun
+	}
+
+	if models.ValidateImportOptions(v, options); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	report, err := h.subscriptionService.Import(c.Request.Context(), body, options)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+
+		switch {
+		case errors.As(err, &maxBytesError):
+			h.contentTooLargeResponse(c, maxBytesError.Limit)
+		case errors.Is(err, service.ErrInvalidImportFile):
+			h.badRequestResponse(c, err)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if report.Imported > 0 {
+		status = http.StatusCreated
+	}
+
+	c.JSON(status, report)
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"bytes"
+	"eff-subscriptions/internal/domain/models"
+	"encoding/json"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// subscriptionDocument the fields of a subscription which can be changed by a patch. Unlike
+// models.UpdateSubscriptionRequest it is used for the whole document, so null values clear the fields.
+type subscriptionDocument struct {
+	ServiceName   *string            `json:"service_name"`
+	Price         *int               `json:"price"`
+	Currency      *string            `json:"currency"`
+	BillingPeriod *string            `json:"billing_period"`
+	UserID        *uuid.UUID         `json:"user_id"`
+	StartDate     *models.CustomDate `json:"start_date"`
+	EndDate       *models.CustomDate `json:"end_date"`
+}
+
+// patchSubscription applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document to the
+// subscription, depending on contentType. Fields removed or set to null are cleared, the result has to be
+// validated by the caller. A failed JSON Patch test operation is reported with jsonpatch.ErrTestFailed.
+func patchSubscription(subscription *models.Subscription, contentType string, patch []byte) error {
+	original, err := json.Marshal(subscriptionDocument{
+		ServiceName:   &subscription.ServiceName,
+		Price:         subscription.Price,
+		Currency:      &subscription.Currency,
+		BillingPeriod: &subscription.BillingPeriod,
+		UserID:        &subscription.UserID,
+		StartDate:     &subscription.StartDate,
+		EndDate:       subscription.EndDate,
+	})
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+
+	switch contentType {
+	case mimeMergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	default:
+		var operations jsonpatch.Patch
+
+		operations, err = jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return err
+		}
+
+		patched, err = operations.Apply(original)
+	}
+	if err != nil {
+		return err
+	}
+
+	var document subscriptionDocument
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(&document)
+	if err != nil {
+		return err
+	}
+
+	subscription.ServiceName = valueOrZero(document.ServiceName)
+	subscription.Price = document.Price
+	subscription.Currency = valueOrZero(document.Currency)
+	subscription.BillingPeriod = valueOrZero(document.BillingPeriod)
+	subscription.UserID = valueOrZero(document.UserID)
+	subscription.StartDate = valueOrZero(document.StartDate)
+	subscription.EndDate = document.EndDate
+
+	return nil
+}
+
+func valueOrZero[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+
+	return *p
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPatchSubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+		want        int
+		check       func(t *testing.T, subscription *models.Subscription)
+	}{
+		{
+			name:        "merge patch clears end date",
+			contentType: mimeMergePatch,
+			patch:       `{"end_date":null}`,
+			want:        http.StatusOK,
+			check: func(t *testing.T, subscription *models.Subscription) {
+				if subscription.EndDate != nil {
+					t.Fatalf("end date: got %v, want none", subscription.EndDate.Time())
+				}
+			},
+		},
+		{
+			name:        "merge patch keeps other fields",
+			contentType: mimeMergePatch,
+			patch:       `{"price":500}`,
+			want:        http.StatusOK,
+			check: func(t *testing.T, subscription *models.Subscription) {
+				if *subscription.Price != 500 || subscription.ServiceName != "Yandex Plus" || subscription.EndDate == nil {
+					t.Fatalf("subscription: got %+v, want price 500 and the other fields unchanged", subscription)
+				}
+			},
+		},
+		{
+			name:        "merge patch clears a required field",
+			contentType: mimeMergePatch,
+			patch:       `{"service_name":null}`,
+			want:        http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "merge patch unknown field",
+			contentType: mimeMergePatch,
+			patch:       `{"name":"Netflix"}`,
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "merge patch id",
+			contentType: mimeMergePatch,
+			patch:       `{"id":100}`,
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "merge patch version",
+			contentType: mimeMergePatch,
+			patch:       `{"version":100}`,
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "JSON patch",
+			contentType: mimeJSONPatch,
+			patch:       `[{"op":"test","path":"/price","value":400},{"op":"replace","path":"/price","value":500},{"op":"remove","path":"/end_date"}]`,
+			want:        http.StatusOK,
+			check: func(t *testing.T, subscription *models.Subscription) {
+				if *subscription.Price != 500 || subscription.EndDate != nil {
+					t.Fatalf("subscription: got %+v, want price 500 without end date", subscription)
+				}
+			},
+		},
+		{
+			name:        "JSON patch failed test",
+			contentType: mimeJSONPatch,
+			patch:       `[{"op":"test","path":"/price","value":300},{"op":"replace","path":"/price","value":500}]`,
+			want:        http.StatusConflict,
+		},
+		{
+			name:        "JSON patch adds id",
+			contentType: mimeJSONPatch,
+			patch:       `[{"op":"add","path":"/id","value":100}]`,
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "JSON patch replaces version",
+			contentType: mimeJSONPatch,
+			patch:       `[{"op":"replace","path":"/version","value":100}]`,
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "JSON patch malformed",
+			contentType: mimeJSONPatch,
+			patch:       `{"op":"replace"}`,
+			want:        http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil, nil)
+
+			subscription := s.insert(t, "Yandex Plus", 400, uuid.New())
+			end := models.CustomDate(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC))
+			subscription.EndDate = &end
+
+			err := s.subscriptions.Update(t.Context(), subscription)
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+
+			target := fmt.Sprintf("/v1/subscriptions/%d", subscription.ID)
+
+			w := s.do(http.MethodPatch, target, "", tt.patch, "Content-Type", tt.contentType)
+			assertStatus(t, w, tt.want)
+
+			w = s.do(http.MethodGet, target, "", "")
+			assertStatus(t, w, http.StatusOK)
+
+			var response models.SubscriptionResponse
+			decodeBody(t, w, &response)
+
+			if tt.check != nil {
+				tt.check(t, response.Subscription)
+				return
+			}
+
+			// Rejected patches must not change the subscription.
+			if response.Subscription.Version != subscription.Version || *response.Subscription.Price != 400 || response.Subscription.EndDate == nil {
+				t.Fatalf("subscription after a rejected patch: got %+v, want it unchanged", response.Subscription)
+			}
+		})
+	}
+}
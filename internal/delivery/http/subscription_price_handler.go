@@ -0,0 +1,138 @@
+package http
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/validator"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// createSubscriptionPrice godoc
+// @Summary Change subscription price
+// @Description Record a subscription price effective from a month. Costs of the previous months keep using the prices that were effective then. A price starting in a future month is used for costs of that month on, but does not replace the current subscription price
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID subscription"
+// @Param input body models.CreateSubscriptionPriceRequest true "Price change"
+// @Success 201 {object} models.SubscriptionPriceResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 422 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id}/prices [post]
+func (h *Handler) createSubscriptionPrice(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	var input models.CreateSubscriptionPriceRequest
+
+	err = c.BindJSON(&input)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	if !ownsSubscription(c, subscription) {
+		h.notFoundResponse(c)
+		return
+	}
+
+	price := &models.SubscriptionPrice{
+		SubscriptionID: subscription.ID,
+		Price:          input.Price,
+		EffectiveDate:  input.EffectiveDate,
+	}
+
+	v := validator.New()
+
+	if models.ValidateSubscriptionPrice(v, price, subscription); !v.Valid() {
+		h.failedValidationResponse(c, v.Errors)
+		return
+	}
+
+	err = h.subscriptionService.InsertPrice(c.Request.Context(), price)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.SubscriptionPriceResponse{Price: price})
+}
+
+// listSubscriptionPrices godoc
+// @Summary Subscription price history
+// @Description Return price changes of the subscription ordered by effective date
+// @Tags prices
+// @Accept  json
+// @Produce  json
+// @Param id path int true "ID subscription"
+// @Success 200 {object} models.SubscriptionPricesResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 429 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Failure 503 {object} errorResponse
+// @Failure 504 {object} errorResponse
+// @Security BearerAuth
+// @Router /v1/subscriptions/{id}/prices [get]
+func (h *Handler) listSubscriptionPrices(c *gin.Context) {
+	id, err := readIDParam(c)
+	if err != nil {
+		h.badRequestResponse(c, err)
+		return
+	}
+
+	subscription, err := h.subscriptionService.Get(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			h.notFoundResponse(c)
+		default:
+			h.serverErrorResponse(c, err)
+		}
+		return
+	}
+
+	if !ownsSubscription(c, subscription) {
+		h.notFoundResponse(c)
+		return
+	}
+
+	prices, err := h.subscriptionService.GetPrices(c.Request.Context(), id)
+	if err != nil {
+		h.serverErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SubscriptionPricesResponse{Prices: prices})
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"slices"
+	"time"
+)
+
+const (
+	APIKeyScopeRead    = "read"
+	APIKeyScopeWrite   = "write"
+	APIKeyScopeReports = "reports"
+	APIKeyScopeAdmin   = "admin"
+)
+
+var APIKeyScopes = []string{APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeReports, APIKeyScopeAdmin}
+
+// APIKeyPrefix starts every API key, so that keys can be told apart from JWTs.
+const APIKeyPrefix = "effk_"
+
+// APIKey a key of a service client. Only the SHA-256 hash of the key is stored, Prefix holds its first
+// characters to recognize the key in lists.
+// @Description API key of a service client, the key itself is shown only once when it is created
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       []byte     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key may be used at the given time.
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+func ValidateAPIKey(v *validator.Validator, key *APIKey) {
+	v.Check(key.Name != "", "name", "must be provided")
+	v.Check(len(key.Name) <= 200, "name", "must not be more than 200 bytes long")
+
+	v.Check(len(key.Scopes) > 0, "scopes", "must contain at least one scope")
+	for i, scope := range key.Scopes {
+		v.Check(validator.PermittedValue(scope, APIKeyScopes...), "scopes", "must contain only read, write, reports, admin")
+		v.Check(!slices.Contains(key.Scopes[:i], scope), "scopes", "must not contain duplicate values")
+	}
+
+	if key.ExpiresAt != nil {
+		v.Check(key.ExpiresAt.After(time.Now()), "expires_at", "must be in the future")
+	}
+}
+
+// CreateAPIKeyRequest API key request struct
+// @Description API key without expiry if expires_at is omitted
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"billing"`
+	Scopes    []string   `json:"scopes" enums:"read,write,reports,admin"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+// APIKeyResponse API key response struct
+// @Description API key, key is returned only when the key is created
+type APIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key,omitempty"`
+}
+
+// APIKeysListResponse API keys list response struct
+// @Description API keys including revoked ones
+type APIKeysListResponse struct {
+	APIKeys []*APIKey `json:"api_keys"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"time"
+)
+
+const DefaultCurrency = "RUB"
+
+// ExchangeRate rate effective from EffectiveDate: 1 BaseCurrency = Rate QuoteCurrency
+type ExchangeRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	EffectiveDate time.Time `json:"effective_date"`
+}
+
+func ValidateCurrency(v *validator.Validator, key string, currency string) {
+	v.Check(validator.Matches(currency, validator.CurrencyRX), key, "must be a valid ISO 4217 currency code")
+}
+
+func ValidateExchangeRate(v *validator.Validator, rate *ExchangeRate) {
+	ValidateCurrency(v, "base_currency", rate.BaseCurrency)
+	ValidateCurrency(v, "quote_currency", rate.QuoteCurrency)
+	v.Check(rate.BaseCurrency != rate.QuoteCurrency, "quote_currency", "must differ from base_currency")
+
+	v.Check(rate.Rate > 0, "rate", "must be greater than zero")
+
+	v.Check(!rate.EffectiveDate.IsZero(), "effective_date", "must be provided")
+}
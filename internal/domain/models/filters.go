@@ -10,15 +10,35 @@ type Filters struct {
 	PageSize     int
 	Sort         string
 	SortSafelist []string
+	// After and Before switch to keyset pagination: the page starts right after
+	// or ends right before the cursor position.
+	After  *Cursor
+	Before *Cursor
+}
+
+// Cursor position in a sorted list: values of the sort keys of a row.
+type Cursor struct {
+	Sort   string   `json:"sort"`
+	Values []string `json:"values"`
+}
+
+// SortKey a column of the sort order.
+type SortKey struct {
+	Column string
+	Desc   bool
 }
 
 // Metadata metadata struct for pagination
 type Metadata struct {
-	CurrentPage  int `json:"current_page,omitzero"`
-	PageSize     int `json:"page_size,omitzero"`
-	FirstPage    int `json:"first_page,omitzero"`
-	LastPage     int `json:"last_page,omitzero"`
-	TotalRecords int `json:"total_records,omitzero"`
+	CurrentPage  int    `json:"current_page,omitzero"`
+	PageSize     int    `json:"page_size,omitzero"`
+	FirstPage    int    `json:"first_page,omitzero"`
+	LastPage     int    `json:"last_page,omitzero"`
+	TotalRecords int    `json:"total_records,omitzero"`
+	NextCursor   string `json:"next_cursor,omitempty"`
+	PrevCursor   string `json:"prev_cursor,omitempty"`
+	HasNext      bool   `json:"-"`
+	HasPrev      bool   `json:"-"`
 }
 
 func ValidateFilters(v *validator.Validator, f Filters) {
@@ -27,29 +47,78 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
 	v.Check(f.PageSize <= 100, "page_size", "must be a maximum of 100")
 
-	v.Check(validator.PermittedValue(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	ValidateSort(v, f)
+
+	v.Check(f.After == nil || f.Before == nil, "after", "must not be used together with before")
+	v.Check(f.Page == 1 || !f.Keyset(), "page", "must not be used together with a cursor")
+
+	for key, cursor := range map[string]*Cursor{"after": f.After, "before": f.Before} {
+		if cursor != nil && v.Valid() {
+			v.Check(cursor.Sort == f.Sort && len(cursor.Values) == len(f.SortKeys()), key, "cursor does not match the sort order")
+		}
+	}
+}
+
+// ValidateSort checks every element of the sort order against the safelist.
+func ValidateSort(v *validator.Validator, f Filters) {
+	columns := make(map[string]bool)
+	for _, value := range f.SortValues() {
+		column := strings.TrimPrefix(value, "-")
+		v.Check(validator.PermittedValue(value, f.SortSafelist...), "sort", "invalid sort value: "+value)
+		v.Check(!columns[column], "sort", "duplicate sort column: "+column)
+		columns[column] = true
+	}
 }
 
-// sortColumn check that the client-provided Sort field matches one of the entries if our safelist
-// and if it does, extract the column name from the Sort field by stripping the leading
-// hyphen character (if one exists).
-func (f Filters) SortColumn() string {
-	for _, safeValue := range f.SortSafelist {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
+// SortValues splits the client-provided Sort field into its comma-separated elements,
+// e.g. "service_name,-price" into "service_name" and "-price".
+func (f Filters) SortValues() []string {
+	values := strings.Split(f.Sort, ",")
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+
+	return values
+}
+
+// SortKeys check that every element of the Sort field matches one of the entries of our safelist
+// and if it does, return the sort order with id as the final tiebreaker. A leading hyphen of an
+// element sorts its column in descending order.
+func (f Filters) SortKeys() []SortKey {
+	var keys []SortKey
+	tiebreaker := true
+
+	for _, value := range f.SortValues() {
+		if !validator.PermittedValue(value, f.SortSafelist...) {
+			panic("unsafe sort paramether: " + f.Sort)
+		}
+
+		key := SortKey{Column: strings.TrimPrefix(value, "-"), Desc: strings.HasPrefix(value, "-")}
+		if key.Column == "id" {
+			tiebreaker = false
 		}
+		keys = append(keys, key)
 	}
 
-	panic("unsafe sort paramether: " + f.Sort)
+	if tiebreaker {
+		keys = append(keys, SortKey{Column: "id"})
+	}
+
+	return keys
 }
 
-// sortDirection return the sort direction ("ASC" or "DESC") depending on the prefix character of the Sort field.
-func (f Filters) SortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
-		return "DESC"
+// Keyset reports whether the page is selected by a cursor instead of the page number.
+func (f Filters) Keyset() bool {
+	return f.After != nil || f.Before != nil
+}
+
+// Cursor returns the cursor of a keyset page and whether the page precedes it.
+func (f Filters) Cursor() (*Cursor, bool) {
+	if f.Before != nil {
+		return f.Before, true
 	}
 
-	return "ASC"
+	return f.After, false
 }
 
 func (f Filters) Limit() int {
@@ -67,11 +136,15 @@ func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
 		return Metadata{}
 	}
 
+	lastPage := (totalRecords + pageSize - 1) / pageSize
+
 	return Metadata{
 		CurrentPage:  page,
 		PageSize:     pageSize,
 		FirstPage:    1,
-		LastPage:     (totalRecords + pageSize - 1) / pageSize,
+		LastPage:     lastPage,
 		TotalRecords: totalRecords,
+		HasNext:      page < lastPage,
+		HasPrev:      page > 1,
 	}
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"time"
+)
+
+// MaxIdempotencyKeyLength the maximum length of an Idempotency-Key header.
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyKey a request sent with an Idempotency-Key header and its response.
+// Status is zero while the request is being processed.
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func ValidateIdempotencyKey(v *validator.Validator, key string) {
+	v.Check(len(key) <= MaxIdempotencyKeyLength, "idempotency_key", "must not be more than 255 bytes long")
+
+	for _, r := range key {
+		if r < ' ' || r > '~' {
+			v.AddError("idempotency_key", "must contain only printable ASCII characters")
+			break
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// RateLimit a token bucket holding up to Burst tokens which refills at Rate tokens per second.
+// Every request takes one token.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitResult the outcome of taking a token from a bucket, Tokens holds the tokens left in the bucket.
+type RateLimitResult struct {
+	Allowed bool
+	Tokens  float64
+}
+
+// Refill returns the tokens of a bucket which held tokens elapsed time ago.
+func (l RateLimit) Refill(tokens float64, elapsed time.Duration) float64 {
+	return min(float64(l.Burst), tokens+max(elapsed.Seconds(), 0)*l.Rate)
+}
+
+// Take takes a token from a bucket holding tokens if there is one.
+func (l RateLimit) Take(tokens float64) RateLimitResult {
+	if tokens < 1 {
+		return RateLimitResult{Allowed: false, Tokens: tokens}
+	}
+
+	return RateLimitResult{Allowed: true, Tokens: tokens - 1}
+}
+
+// TimeUntil returns how long it takes a bucket holding tokens to refill to want tokens.
+func (l RateLimit) TimeUntil(tokens float64, want float64) time.Duration {
+	if tokens >= want {
+		return 0
+	}
+
+	return time.Duration((want - tokens) / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package models_test
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRefill(t *testing.T) {
+	limit := models.RateLimit{Rate: 2, Burst: 3}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time elapsed", 0.5, 0, 0.5},
+		{"half a second", 0, 500 * time.Millisecond, 1},
+		{"partial token", 1, 250 * time.Millisecond, 1.5},
+		{"capped at burst", 1, 10 * time.Second, 3},
+		{"full bucket", 3, time.Second, 3},
+		{"clock went back", 2, -time.Second, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.Refill(tt.tokens, tt.elapsed); got != tt.want {
+				t.Fatalf("Refill(%v, %v): got %v, want %v", tt.tokens, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitTake(t *testing.T) {
+	limit := models.RateLimit{Rate: 1, Burst: 3}
+
+	tests := []struct {
+		tokens float64
+		want   models.RateLimitResult
+	}{
+		{0, models.RateLimitResult{Allowed: false, Tokens: 0}},
+		{0.5, models.RateLimitResult{Allowed: false, Tokens: 0.5}},
+		{1, models.RateLimitResult{Allowed: true, Tokens: 0}},
+		{2.5, models.RateLimitResult{Allowed: true, Tokens: 1.5}},
+	}
+
+	for _, tt := range tests {
+		if got := limit.Take(tt.tokens); got != tt.want {
+			t.Fatalf("Take(%v): got %+v, want %+v", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitTimeUntil(t *testing.T) {
+	limit := models.RateLimit{Rate: 2, Burst: 3}
+
+	tests := []struct {
+		tokens float64
+		want   float64
+		result time.Duration
+	}{
+		{0, 1, 500 * time.Millisecond},
+		{0.5, 1, 250 * time.Millisecond},
+		{1, 1, 0},
+		{2, 1, 0},
+		{0, 3, 1500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := limit.TimeUntil(tt.tokens, tt.want); got != tt.result {
+			t.Fatalf("TimeUntil(%v, %v): got %v, want %v", tt.tokens, tt.want, got, tt.result)
+		}
+	}
+}
+
+// TestRateLimitBucket follows a bucket through a series of requests at fixed times.
+func TestRateLimitBucket(t *testing.T) {
+	limit := models.RateLimit{Rate: 1, Burst: 2}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		at         time.Duration
+		wantAllow  bool
+		wantTokens float64
+		wantRetry  time.Duration
+	}{
+		{0, true, 1, 0},
+		{0, true, 0, 0},
+		{0, false, 0, time.Second},
+		{500 * time.Millisecond, false, 0.5, 500 * time.Millisecond},
+		{time.Second, true, 0, 0},
+		{10 * time.Second, true, 1, 0},
+	}
+
+	tokens, updatedAt := float64(limit.Burst), start
+
+	for i, step := range steps {
+		now := start.Add(step.at)
+
+		result := limit.Take(limit.Refill(tokens, now.Sub(updatedAt)))
+		tokens, updatedAt = result.Tokens, now
+
+		if result.Allowed != step.wantAllow || result.Tokens != step.wantTokens {
+			t.Fatalf("request %d at %v: got %+v, want allowed %t with %v tokens", i, step.at, result, step.wantAllow, step.wantTokens)
+		}
+
+		if !result.Allowed {
+			if retry := limit.TimeUntil(result.Tokens, 1); retry != step.wantRetry {
+				t.Fatalf("request %d at %v: retry after %v, want %v", i, step.at, retry, step.wantRetry)
+			}
+		}
+	}
+}
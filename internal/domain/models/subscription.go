@@ -3,18 +3,100 @@ package models
 import (
 	"eff-subscriptions/internal/validator"
 	"github.com/google/uuid"
+	"strconv"
 	"time"
 )
 
+const (
+	BillingPeriodWeekly    = "weekly"
+	BillingPeriodMonthly   = "monthly"
+	BillingPeriodQuarterly = "quarterly"
+	BillingPeriodYearly    = "yearly"
+)
+
+var BillingPeriods = []string{BillingPeriodWeekly, BillingPeriodMonthly, BillingPeriodQuarterly, BillingPeriodYearly}
+
+// MonthlyFactor returns the coefficient converting a price billed with the period to the equivalent monthly cost.
+func MonthlyFactor(billingPeriod string) float64 {
+	switch billingPeriod {
+	case BillingPeriodWeekly:
+		return 52.0 / 12
+	case BillingPeriodQuarterly:
+		return 1.0 / 3
+	case BillingPeriodYearly:
+		return 1.0 / 12
+	default:
+		return 1
+	}
+}
+
 type Subscription struct {
-	ID          int         `json:"id"`
-	ServiceName string      `json:"service_name"`
-	Price       *int        `json:"price"`
-	UserID      uuid.UUID   `json:"user_id"`
-	StartDate   CustomDate  `json:"start_date"`
-	EndDate     *CustomDate `json:"end_date,omitzero"`
-	CreatedAt   time.Time   `json:"-"`
-	Version     int         `json:"version"`
+	ID            int         `json:"id"`
+	ServiceName   string      `json:"service_name"`
+	Price         *int        `json:"price"`
+	Currency      string      `json:"currency"`
+	BillingPeriod string      `json:"billing_period"`
+	UserID        uuid.UUID   `json:"user_id"`
+	StartDate     CustomDate  `json:"start_date"`
+	EndDate       *CustomDate `json:"end_date,omitzero"`
+	CreatedAt     time.Time   `json:"-"`
+	DeletedAt     *time.Time  `json:"deleted_at,omitempty"`
+	Version       int         `json:"version"`
+
+	// Converted price in the currency requested by the client, filled only by list queries
+	Converted *ConvertedPrice `json:"converted,omitempty"`
+}
+
+// SortValue returns the value of a sortable column as it is stored in cursors.
+// Dates use the YYYY-MM-DD format and timestamps RFC 3339 in UTC.
+func (s *Subscription) SortValue(column string) string {
+	switch column {
+	case "service_name":
+		return s.ServiceName
+	case "price":
+		return strconv.Itoa(*s.Price)
+	case "start_date":
+		return s.StartDate.Time().Format(time.DateOnly)
+	case "deleted_at":
+		if s.DeletedAt == nil {
+			return ""
+		}
+		return s.DeletedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return strconv.Itoa(s.ID)
+	}
+}
+
+// BillingMonth returns the month the current price of the subscription is charged for: the month of now while
+// the subscription is active, its first month before it starts and its last month after it ends.
+func (s *Subscription) BillingMonth(now time.Time) time.Time {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch {
+	case month.Before(s.StartDate.Time()):
+		return s.StartDate.Time()
+	case s.EndDate != nil && month.After(s.EndDate.Time()):
+		return s.EndDate.Time()
+	default:
+		return month
+	}
+}
+
+// NewSubscriptionCursor returns the position of the subscription in the sort order of filters.
+func NewSubscriptionCursor(filters Filters, subscription *Subscription) Cursor {
+	cursor := Cursor{Sort: filters.Sort}
+	for _, key := range filters.SortKeys() {
+		cursor.Values = append(cursor.Values, subscription.SortValue(key.Column))
+	}
+
+	return cursor
+}
+
+// ConvertedPrice subscription price converted to another currency
+// @Description price converted to the requested currency
+type ConvertedPrice struct {
+	Price    int    `json:"price"`
+	Currency string `json:"currency"`
 }
 
 func ValidateSubscription(v *validator.Validator, subscription *Subscription) {
@@ -22,7 +104,13 @@ func ValidateSubscription(v *validator.Validator, subscription *Subscription) {
 	v.Check(len(subscription.ServiceName) <= 500, "service_name", "must not be more than 500 bytes long")
 
 	v.Check(subscription.Price != nil, "price", "must be provided")
-	v.Check(*subscription.Price > -1, "price", "must be a positive integer")
+	if subscription.Price != nil {
+		v.Check(*subscription.Price > -1, "price", "must be a positive integer")
+	}
+
+	ValidateCurrency(v, "currency", subscription.Currency)
+
+	v.Check(validator.PermittedValue(subscription.BillingPeriod, BillingPeriods...), "billing_period", "must be one of weekly, monthly, quarterly, yearly")
 
 	v.Check(subscription.UserID != uuid.Nil, "user_id", "must not be empty")
 
@@ -35,21 +123,25 @@ func ValidateSubscription(v *validator.Validator, subscription *Subscription) {
 // CreateSubscriptionRequest subscription request struct
 // @Description subscription
 type CreateSubscriptionRequest struct {
-	ServiceName string      `json:"service_name"`
-	Price       *int        `json:"price"`
-	UserID      uuid.UUID   `json:"user_id"`
-	StartDate   CustomDate  `json:"start_date"`
-	EndDate     *CustomDate `json:"end_date,omitzero"`
+	ServiceName   string      `json:"service_name"`
+	Price         *int        `json:"price"`
+	Currency      string      `json:"currency" example:"RUB" default:"RUB"`
+	BillingPeriod string      `json:"billing_period" enums:"weekly,monthly,quarterly,yearly" default:"monthly"`
+	UserID        uuid.UUID   `json:"user_id"`
+	StartDate     CustomDate  `json:"start_date"`
+	EndDate       *CustomDate `json:"end_date,omitzero"`
 }
 
 // UpdateSubscriptionRequest subscription request struct for update
 // @Description update subscription struct
 type UpdateSubscriptionRequest struct {
-	ServiceName *string     `json:"service_name"`
-	Price       *int        `json:"price"`
-	UserID      *uuid.UUID  `json:"user_id"`
-	StartDate   *CustomDate `json:"start_date"`
-	EndDate     *CustomDate `json:"end_date,omitzero"`
+	ServiceName   *string     `json:"service_name"`
+	Price         *int        `json:"price"`
+	Currency      *string     `json:"currency" example:"USD"`
+	BillingPeriod *string     `json:"billing_period" enums:"weekly,monthly,quarterly,yearly"`
+	UserID        *uuid.UUID  `json:"user_id"`
+	StartDate     *CustomDate `json:"start_date"`
+	EndDate       *CustomDate `json:"end_date,omitzero"`
 }
 
 // SubscriptionResponse subscription response struct
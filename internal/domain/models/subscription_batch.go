@@ -0,0 +1,54 @@
+package models
+
+const (
+	BatchOperationCreate = "create"
+	BatchOperationUpdate = "update"
+	BatchOperationDelete = "delete"
+)
+
+var BatchOperations = []string{BatchOperationCreate, BatchOperationUpdate, BatchOperationDelete}
+
+// MaxBatchOperations the maximum number of operations in one batch request.
+const MaxBatchOperations = 100
+
+// BatchOperation a change applied as a part of a batch. Create and update store Subscription,
+// delete uses only its ID. Err is set by the storage when the operation fails with
+// a record not found or an edit conflict error.
+type BatchOperation struct {
+	Op           string
+	Subscription *Subscription
+	Err          error
+}
+
+// BatchRequest bulk changes request struct
+// @Description create, update and delete operations applied in one transaction
+type BatchRequest struct {
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest a single operation of a batch request
+// @Description operation of a batch: create takes subscription, update takes id, subscription fields to change
+// @Description and optionally the expected version, delete takes id
+type BatchOperationRequest struct {
+	Op           string                     `json:"op" enums:"create,update,delete"`
+	ID           int                        `json:"id,omitempty"`
+	Version      *int                       `json:"version,omitempty"`
+	Subscription *UpdateSubscriptionRequest `json:"subscription,omitempty"`
+}
+
+// BatchResult result of a single operation of a batch
+// @Description HTTP status and the stored subscription or the error of an operation,
+// @Description operations not applied because another operation failed have status 424
+type BatchResult struct {
+	Index        int           `json:"index"`
+	Op           string        `json:"op"`
+	Status       int           `json:"status"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        any           `json:"error,omitempty"`
+}
+
+// BatchResponse bulk changes response struct
+// @Description results of the operations in the order of the request
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
@@ -0,0 +1,16 @@
+package models
+
+const (
+	ExportFormatCSV   = "csv"
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+)
+
+var ExportFormats = []string{ExportFormatCSV, ExportFormatJSONL, ExportFormatXLSX}
+
+// ExportColumns columns of CSV and XLSX exports. They match the columns of the CSV import,
+// so an exported file can be imported back.
+var ExportColumns = []string{"id", "service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date", "version"}
+
+// ExportConvertedColumns columns added to exports when prices are converted to another currency.
+var ExportConvertedColumns = []string{"converted_price", "converted_currency"}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	HistoryOperationSnapshot = "snapshot"
+	HistoryOperationInsert   = "insert"
+	HistoryOperationUpdate   = "update"
+	HistoryOperationDelete   = "delete"
+	HistoryOperationRestore  = "restore"
+	HistoryOperationPurge    = "purge"
+)
+
+// SubscriptionHistory immutable record of a single change of a subscription
+// @Description change of a subscription with values before and after it
+type SubscriptionHistory struct {
+	ID             int           `json:"id"`
+	SubscriptionID int           `json:"subscription_id"`
+	Version        int           `json:"version"`
+	Operation      string        `json:"operation" enums:"snapshot,insert,update,delete,restore,purge"`
+	ChangedBy      string        `json:"changed_by"`
+	ChangedAt      time.Time     `json:"changed_at"`
+	ChangedFields  []string      `json:"changed_fields"`
+	OldValues      *Subscription `json:"old_values"`
+	NewValues      *Subscription `json:"new_values"`
+}
+
+// SubscriptionHistoryResponse subscription history response struct
+// @Description subscription changes ordered by version
+type SubscriptionHistoryResponse struct {
+	History []*SubscriptionHistory `json:"history"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"unicode/utf8"
+)
+
+// ImportFields subscription fields which can be imported from a CSV file.
+var ImportFields = []string{"service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date"}
+
+// RequiredImportFields fields which must have a column in an imported file,
+// the others fall back to their defaults when the column is missing.
+var RequiredImportFields = []string{"service_name", "price", "user_id", "start_date"}
+
+// MaxImportRows the maximum number of rows in an imported file.
+const MaxImportRows = 10_000
+
+// ImportOptions options of a CSV import. Columns maps subscription fields to the names of the CSV columns,
+// fields which are not mapped are read from the columns with the same name.
+type ImportOptions struct {
+	Columns   map[string]string
+	Delimiter string
+	DryRun    bool
+}
+
+func ValidateImportOptions(v *validator.Validator, options ImportOptions) {
+	for field, column := range options.Columns {
+		v.Check(validator.PermittedValue(field, ImportFields...), "columns", "unknown field "+field)
+		v.Check(column != "", "columns", "must not map "+field+" to an empty column name")
+	}
+
+	r, size := utf8.DecodeRuneInString(options.Delimiter)
+	v.Check(size == len(options.Delimiter) && r != utf8.RuneError, "delimiter", "must be a single character")
+	v.Check(r != '"' && r != '\r' && r != '\n', "delimiter", "must not be a quote or a line break")
+}
+
+// ImportRowError validation errors of a row of an imported file
+// @Description validation errors of a row, rows are numbered by the lines of the file starting from the header
+type ImportRowError struct {
+	Row    int               `json:"row"`
+	Errors map[string]string `json:"errors"`
+}
+
+// ImportReport result of a CSV import
+// @Description numbers of valid and invalid rows, validation errors and the number of imported subscriptions
+type ImportReport struct {
+	DryRun      bool             `json:"dry_run"`
+	TotalRows   int              `json:"total_rows"`
+	ValidRows   int              `json:"valid_rows"`
+	InvalidRows int              `json:"invalid_rows"`
+	Imported    int              `json:"imported"`
+	Errors      []ImportRowError `json:"errors"`
+}
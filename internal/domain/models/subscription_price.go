@@ -0,0 +1,48 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"time"
+)
+
+// SubscriptionPrice subscription price effective from the EffectiveDate month
+// @Description subscription price effective from a month
+type SubscriptionPrice struct {
+	ID             int        `json:"id"`
+	SubscriptionID int        `json:"subscription_id"`
+	Price          *int       `json:"price"`
+	EffectiveDate  CustomDate `json:"effective_date"`
+	CreatedAt      time.Time  `json:"-"`
+}
+
+func ValidateSubscriptionPrice(v *validator.Validator, price *SubscriptionPrice, subscription *Subscription) {
+	v.Check(price.Price != nil, "price", "must be provided")
+	if price.Price != nil {
+		v.Check(*price.Price > -1, "price", "must be a positive integer")
+	}
+
+	v.Check(price.EffectiveDate != CustomDate{}, "effective_date", "must be provided")
+	v.Check(!price.EffectiveDate.Time().Before(subscription.StartDate.Time()), "effective_date", "must not be before start_date of the subscription")
+	if subscription.EndDate != nil {
+		v.Check(!price.EffectiveDate.Time().After(subscription.EndDate.Time()), "effective_date", "must not be after end_date of the subscription")
+	}
+}
+
+// CreateSubscriptionPriceRequest price change request struct
+// @Description price change effective from a month
+type CreateSubscriptionPriceRequest struct {
+	Price         *int       `json:"price"`
+	EffectiveDate CustomDate `json:"effective_date"`
+}
+
+// SubscriptionPriceResponse price response struct
+// @Description subscription price
+type SubscriptionPriceResponse struct {
+	Price *SubscriptionPrice `json:"price"`
+}
+
+// SubscriptionPricesResponse price history response struct
+// @Description subscription price history ordered by effective date
+type SubscriptionPricesResponse struct {
+	Prices []*SubscriptionPrice `json:"prices"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"eff-subscriptions/internal/validator"
+	"github.com/google/uuid"
+)
+
+// maxFilterValues limits the number of values of a multi-value filter.
+const maxFilterValues = 100
+
+// SubscriptionsFilter conditions of the subscriptions list. Prices equal to -1, zero dates,
+// empty lists and a nil HasEndDate mean that there is no such condition.
+type SubscriptionsFilter struct {
+	// ServiceNames matches subscriptions whose service name contains all words of any of the names.
+	ServiceNames []string
+	UserIDs      []uuid.UUID
+	Price        int
+	PriceMin     int
+	PriceMax     int
+	StartDate    CustomDate
+	StartFrom    CustomDate
+	StartTo      CustomDate
+	EndFrom      CustomDate
+	EndTo        CustomDate
+	// ActiveOn matches subscriptions active in the month: started on or before it and not ended before it.
+	ActiveOn   CustomDate
+	HasEndDate *bool
+}
+
+func ValidateSubscriptionsFilter(v *validator.Validator, filter SubscriptionsFilter) {
+	v.Check(len(filter.ServiceNames) <= maxFilterValues, "service_name", "must contain at most 100 values")
+	for _, serviceName := range filter.ServiceNames {
+		v.Check(len(serviceName) <= 500, "service_name", "must not be more than 500 bytes long")
+	}
+	v.Check(len(filter.UserIDs) <= maxFilterValues, "user_id", "must contain at most 100 values")
+
+	v.Check(filter.PriceMin >= -1, "price_min", "must be a positive integer")
+	v.Check(filter.PriceMax >= -1, "price_max", "must be a positive integer")
+	if filter.PriceMin != -1 && filter.PriceMax != -1 {
+		v.Check(filter.PriceMin <= filter.PriceMax, "price_min", "must not be greater than price_max")
+	}
+
+	if filter.StartFrom != (CustomDate{}) && filter.StartTo != (CustomDate{}) {
+		v.Check(!filter.StartFrom.Time().After(filter.StartTo.Time()), "start_from", "must be before start_to")
+	}
+	if filter.EndFrom != (CustomDate{}) && filter.EndTo != (CustomDate{}) {
+		v.Check(!filter.EndFrom.Time().After(filter.EndTo.Time()), "end_from", "must be before end_to")
+	}
+
+	if filter.HasEndDate != nil && !*filter.HasEndDate {
+		v.Check(filter.EndFrom == CustomDate{} && filter.EndTo == CustomDate{}, "has_end_date", "must not be false when end_from or end_to is set")
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+// MonthlySum amount spent on subscriptions during a single month
+// @Description amount spent on subscriptions during a month
+type MonthlySum struct {
+	Month CustomDate `json:"month"`
+	Sum   int        `json:"sum"`
+}
+
+// SubscriptionsSum total amount spent on subscriptions over a period
+// @Description total amount spent over a period with per-month breakdown
+type SubscriptionsSum struct {
+	Currency string       `json:"currency"`
+	Total    int          `json:"total"`
+	Months   []MonthlySum `json:"months"`
+}
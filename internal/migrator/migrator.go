@@ -0,0 +1,433 @@
+// Package migrator applies versioned SQL migrations and records applied versions
+// in the schema_migrations table.
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// lockID key of the advisory lock which prevents concurrent migrations.
+const lockID = 7_340_245_017
+
+// Dialect of the SQL database migrations are applied to.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+var (
+	ErrUnknownVersion = errors.New("unknown migration version")
+
+	fileNameRX = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status of a single migration.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []*Migration
+}
+
+// New reads <version>_<name>.up.sql and <version>_<name>.down.sql files from the root of fsys.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNameRX.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, match[2])
+		}
+
+		switch match[3] {
+		case "up":
+			migration.Up = string(data)
+		case "down":
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return a.Version - b.Version
+	})
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Latest returns the version of the newest known migration.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the newest applied migration version, 0 if none was applied.
+func (m *Migrator) Version() (int, error) {
+	ctx := context.Background()
+
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = m.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations;`).Scan(&version)
+
+	return version, err
+}
+
+// Pending returns known migrations which have not been applied yet.
+func (m *Migrator) Pending() ([]*Migration, error) {
+	applied, err := m.applied(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Status returns all known migrations with the time they were applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Up applies all pending migrations and returns the number of applied migrations.
+func (m *Migrator) Up() (int, error) {
+	return m.migrate(m.Latest(), true)
+}
+
+// Down rolls back the given number of the newest applied migrations.
+func (m *Migrator) Down(steps int) (int, error) {
+	applied, err := m.applied(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	versions := make([]int, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+
+	target := 0
+	if steps < len(versions) {
+		target = versions[len(versions)-steps-1]
+	}
+
+	return m.migrate(target, false)
+}
+
+// Goto applies or rolls back migrations so that every migration up to and including
+// version is applied and every newer one is not. Version 0 rolls back everything.
+func (m *Migrator) Goto(version int) (int, error) {
+	if version != 0 && !m.known(version) {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.migrate(version, true)
+}
+
+// Force marks migrations up to and including version as applied and newer ones as not applied
+// without running them. It is meant for databases created before migrations were tracked.
+func (m *Migrator) Force(version int) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	ctx := context.Background()
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations;`)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, name) VALUES ($1, $2);`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Seed executes every .sql file from the root of fsys in lexical order inside a single transaction.
+func (m *Migrator) Seed(fsys fs.FS) (int, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+	slices.Sort(names)
+
+	ctx := context.Background()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, string(data))
+		if err != nil {
+			return 0, fmt.Errorf("seed %s: %w", name, err)
+		}
+	}
+
+	return len(names), tx.Commit()
+}
+
+// migrate rolls back applied migrations newer than target and, if apply is set, applies pending
+// migrations up to and including target. Every migration runs in its own transaction.
+func (m *Migrator) migrate(target int, apply bool) (int, error) {
+	ctx := context.Background()
+	count := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok || migration.Version <= target {
+				continue
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			err = m.run(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE version = $1;`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("rollback %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > target || !apply {
+				continue
+			}
+
+			err = m.run(ctx, conn, migration.Up, `INSERT INTO schema_migrations(version, name) VALUES ($1, $2);`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("apply %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// run executes the migration script and bookkeeping query in one transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script string, bookkeeping string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, bookkeeping, args...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// withLock runs fn holding an advisory lock so that several instances starting at once
+// do not apply the same migrations. SQLite has no advisory locks, a database file is expected
+// to be used by a single instance.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.dialect == SQLite {
+		return fn(conn)
+	}
+
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, lockID)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1);`, lockID)
+
+	return fn(conn)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	query := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+		);`
+
+	if m.dialect == SQLite {
+		query = `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);`
+	}
+
+	_, err := m.db.ExecContext(ctx, query)
+
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		err := rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) known(version int) bool {
+	return slices.ContainsFunc(m.migrations, func(migration *Migration) bool {
+		return migration.Version == version
+	})
+}
@@ -0,0 +1,23 @@
+package repository
+
+import "context"
+
+// SystemActor the author of changes which are not made on behalf of a caller, such as purging the trash.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the author of the changes made with it.
+// The author is recorded in the subscription history.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the author of the changes made with ctx, SystemActor if it has not been set.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return SystemActor
+}
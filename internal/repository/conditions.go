@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Conditions collects the conditions of a WHERE clause together with their arguments.
+// Placeholders are numbered in the order arguments are added, so optional conditions
+// are added only when they are set instead of being disabled by a sentinel argument.
+type Conditions struct {
+	clauses []string
+	args    []any
+}
+
+// Add appends a condition. Every ? in clause is replaced with the placeholder of the next argument.
+func (c *Conditions) Add(clause string, args ...any) {
+	var b strings.Builder
+
+	for _, arg := range args {
+		before, after, found := strings.Cut(clause, "?")
+		if !found {
+			panic("repository: condition has fewer placeholders than arguments: " + clause)
+		}
+
+		b.WriteString(before)
+		b.WriteString(c.Arg(arg))
+		clause = after
+	}
+	b.WriteString(clause)
+
+	c.clauses = append(c.clauses, b.String())
+}
+
+// AddAny appends a condition that holds when clause holds for any of values.
+// Clause must contain a single ? placeholder.
+func (c *Conditions) AddAny(clause string, values []any) {
+	if len(values) == 0 {
+		return
+	}
+
+	clauses := make([]string, len(values))
+	for i := range values {
+		clauses[i] = clause
+	}
+
+	c.Add("("+strings.Join(clauses, " OR ")+")", values...)
+}
+
+// Arg adds an argument which is not part of a condition and returns its placeholder.
+func (c *Conditions) Arg(arg any) string {
+	c.args = append(c.args, arg)
+
+	return fmt.Sprintf("$%d", len(c.args))
+}
+
+// SQL returns the conditions joined with AND.
+func (c *Conditions) SQL() string {
+	if len(c.clauses) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(c.clauses, " AND ")
+}
+
+func (c *Conditions) Args() []any {
+	return c.args
+}
+
+// SubscriptionConditions returns the conditions selecting subscriptions which are not deleted and match filter.
+// search is the storage specific condition matching service_name against a single ? name and date
+// converts dates to arguments of the storage.
+func SubscriptionConditions(filter models.SubscriptionsFilter, search string, date func(time.Time) any) *Conditions {
+	conditions := &Conditions{}
+	conditions.Add("deleted_at IS NULL")
+
+	conditions.AddAny(search, Values(filter.ServiceNames))
+	conditions.AddAny("user_id = ?", Values(filter.UserIDs))
+
+	if filter.Price != -1 {
+		conditions.Add("price = ?", filter.Price)
+	}
+	if filter.PriceMin != -1 {
+		conditions.Add("price >= ?", filter.PriceMin)
+	}
+	if filter.PriceMax != -1 {
+		conditions.Add("price <= ?", filter.PriceMax)
+	}
+
+	for _, c := range []struct {
+		clause string
+		date   models.CustomDate
+	}{
+		{"start_date = ?", filter.StartDate},
+		{"start_date >= ?", filter.StartFrom},
+		{"start_date <= ?", filter.StartTo},
+		{"end_date >= ?", filter.EndFrom},
+		{"end_date <= ?", filter.EndTo},
+	} {
+		if c.date != (models.CustomDate{}) {
+			conditions.Add(c.clause, date(c.date.Time()))
+		}
+	}
+
+	if filter.ActiveOn != (models.CustomDate{}) {
+		month := date(filter.ActiveOn.Time())
+		conditions.Add("start_date <= ? AND (end_date IS NULL OR end_date >= ?)", month, month)
+	}
+
+	if filter.HasEndDate != nil {
+		if *filter.HasEndDate {
+			conditions.Add("end_date IS NOT NULL")
+		} else {
+			conditions.Add("end_date IS NULL")
+		}
+	}
+
+	return conditions
+}
+
+// Values converts values to query arguments.
+func Values[T any](values []T) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
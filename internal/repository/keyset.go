@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"eff-subscriptions/internal/domain/models"
+	"slices"
+	"strings"
+)
+
+// OrderBySQL returns the ORDER BY list for keys. The order is reversed when backward is set,
+// which is used to read the page preceding a cursor.
+func OrderBySQL(keys []models.SortKey, backward bool) string {
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		direction := "ASC"
+		if key.Desc != backward {
+			direction = "DESC"
+		}
+		columns = append(columns, key.Column+" "+direction)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// KeysetSQL returns a condition selecting rows that follow the cursor position values in the order
+// of keys, or precede it when backward is set, with ? placeholders and their arguments for Conditions.Add.
+func KeysetSQL(keys []models.SortKey, backward bool, values []string) (string, []any) {
+	var conditions []string
+	var args []any
+
+	for i, key := range keys {
+		var terms []string
+		for j := range i {
+			terms = append(terms, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+
+		operator := ">"
+		if key.Desc != backward {
+			operator = "<"
+		}
+		terms = append(terms, key.Column+" "+operator+" ?")
+		args = append(args, values[i])
+
+		conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// KeysetPage turns rows read with a limit of one more than the page size into a page.
+// The extra row tells whether there are more rows in the reading direction. Rows read
+// backward are put back into the sort order.
+func KeysetPage(subscriptions []*models.Subscription, filters models.Filters) ([]*models.Subscription, models.Metadata) {
+	_, backward := filters.Cursor()
+
+	more := len(subscriptions) > filters.Limit()
+	if more {
+		subscriptions = subscriptions[:filters.Limit()]
+	}
+
+	if len(subscriptions) == 0 {
+		return nil, models.Metadata{}
+	}
+
+	metadata := models.Metadata{PageSize: filters.PageSize}
+
+	if backward {
+		slices.Reverse(subscriptions)
+		metadata.HasPrev, metadata.HasNext = more, true
+	} else {
+		metadata.HasNext, metadata.HasPrev = more, true
+	}
+
+	return subscriptions, metadata
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"slices"
+	"sync"
+	"time"
+)
+
+type APIKeyRepository struct {
+	mu     sync.Mutex
+	keys   map[int]*models.APIKey
+	lastID int
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{keys: make(map[int]*models.APIKey)}
+}
+
+func (r *APIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	key.ID = r.lastID
+	key.CreatedAt = time.Now().UTC()
+
+	r.keys[key.ID] = copyAPIKey(key)
+
+	return nil
+}
+
+// GetAll returns all keys including revoked and expired ones.
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]*models.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, copyAPIKey(key))
+	}
+
+	slices.SortFunc(keys, func(a, b *models.APIKey) int {
+		return a.ID - b.ID
+	})
+
+	return keys, nil
+}
+
+// GetByHash returns the key with the hash unless it has been revoked.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*models.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range r.keys {
+		if key.RevokedAt == nil && bytes.Equal(key.Hash, hash) {
+			return copyAPIKey(key), nil
+		}
+	}
+
+	return nil, repository.ErrRecordNotFound
+}
+
+// Revoke marks the key as revoked, it cannot be used afterwards.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return repository.ErrRecordNotFound
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+
+	return nil
+}
+
+// Touch records when the key was last used.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key, ok := r.keys[id]; ok {
+		key.LastUsedAt = &usedAt
+	}
+
+	return nil
+}
+
+func copyAPIKey(key *models.APIKey) *models.APIKey {
+	c := *key
+	c.Hash = slices.Clone(key.Hash)
+	c.Scopes = slices.Clone(key.Scopes)
+
+	return &c
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+type currencyPair struct {
+	base  string
+	quote string
+}
+
+type ExchangeRateRepository struct {
+	mu    sync.RWMutex
+	rates map[currencyPair][]*models.ExchangeRate
+}
+
+func NewExchangeRateRepository() *ExchangeRateRepository {
+	return &ExchangeRateRepository{rates: make(map[currencyPair][]*models.ExchangeRate)}
+}
+
+// InsertMany stores rates replacing already known rates for the same currency pair and effective date.
+func (r *ExchangeRateRepository) InsertMany(ctx context.Context, rates []*models.ExchangeRate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rate := range rates {
+		pair := currencyPair{base: rate.BaseCurrency, quote: rate.QuoteCurrency}
+		stored := *rate
+
+		known := r.rates[pair]
+		i, found := slices.BinarySearchFunc(known, rate.EffectiveDate, func(rate *models.ExchangeRate, date time.Time) int {
+			return rate.EffectiveDate.Compare(date)
+		})
+		if found {
+			known[i] = &stored
+			continue
+		}
+
+		r.rates[pair] = slices.Insert(known, i, &stored)
+	}
+
+	return nil
+}
+
+// rate returns the rate converting from currency to target effective on date. Rates stored
+// in the opposite direction are inverted, the most recent of both directions wins.
+func (r *ExchangeRateRepository) rate(from, to string, date time.Time) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	direct := latestRate(r.rates[currencyPair{base: from, quote: to}], date)
+	inverse := latestRate(r.rates[currencyPair{base: to, quote: from}], date)
+
+	switch {
+	case direct == nil && inverse == nil:
+		return 0, false
+	case inverse == nil || (direct != nil && !direct.EffectiveDate.Before(inverse.EffectiveDate)):
+		return direct.Rate, true
+	default:
+		return 1 / inverse.Rate, true
+	}
+}
+
+// latestRate returns the latest of rates sorted by effective date which is effective on date.
+func latestRate(rates []*models.ExchangeRate, date time.Time) *models.ExchangeRate {
+	i, found := slices.BinarySearchFunc(rates, date, func(rate *models.ExchangeRate, date time.Time) int {
+		return rate.EffectiveDate.Compare(date)
+	})
+	if found {
+		return rates[i]
+	}
+	if i == 0 {
+		return nil
+	}
+
+	return rates[i-1]
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"slices"
+	"sync"
+	"time"
+)
+
+type IdempotencyKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]*models.IdempotencyKey
+}
+
+func NewIdempotencyKeyRepository() *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{keys: make(map[string]*models.IdempotencyKey)}
+}
+
+// Reserve stores the key unless a key with the same name exists and has not expired by key.CreatedAt.
+// A key still being processed which was reserved at or before staleBefore is replaced as well.
+// It returns nil if the key was stored and the existing key otherwise.
+func (r *IdempotencyKeyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey, staleBefore time.Time) (*models.IdempotencyKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.keys[key.Key]; ok && existing.ExpiresAt.After(key.CreatedAt) {
+		stale := existing.Status == 0 && !existing.CreatedAt.After(staleBefore)
+		if !stale {
+			return copyIdempotencyKey(existing), nil
+		}
+	}
+
+	stored := copyIdempotencyKey(key)
+	stored.Status = 0
+	stored.Response = nil
+	r.keys[stored.Key] = stored
+
+	return nil, nil
+}
+
+// Complete stores the response of the request reserved by the key.
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, key string, status int, response []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[key]
+	if !ok {
+		return repository.ErrRecordNotFound
+	}
+
+	stored.Status = status
+	stored.Response = slices.Clone(response)
+
+	return nil
+}
+
+// Delete releases the key so that the request can be retried.
+func (r *IdempotencyKeyRepository) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.keys, key)
+
+	return nil
+}
+
+// Purge deletes keys which expired before the given time and returns the number of deleted keys.
+func (r *IdempotencyKeyRepository) Purge(ctx context.Context, expiredBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for name, key := range r.keys {
+		if key.ExpiresAt.Before(expiredBefore) {
+			delete(r.keys, name)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func copyIdempotencyKey(key *models.IdempotencyKey) *models.IdempotencyKey {
+	c := *key
+	c.Response = slices.Clone(key.Response)
+
+	return &c
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"sync"
+	"time"
+)
+
+type rateLimitBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// RateLimitRepository keeps token buckets in memory, each instance of the server limits requests on its own.
+type RateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]*rateLimitBucket
+}
+
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{buckets: make(map[string]*rateLimitBucket)}
+}
+
+// Take refills the bucket with the key and takes a token from it if there is one. A missing bucket is full.
+func (r *RateLimitRepository) Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error) {
+	if err := ctx.Err(); err != nil {
+		return models.RateLimitResult{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.buckets[key]
+	if !ok {
+		bucket = &rateLimitBucket{tokens: float64(limit.Burst), updatedAt: now}
+		r.buckets[key] = bucket
+	}
+
+	result := limit.Take(limit.Refill(bucket.tokens, now.Sub(bucket.updatedAt)))
+
+	bucket.tokens = result.Tokens
+	if now.After(bucket.updatedAt) {
+		bucket.updatedAt = now
+	}
+
+	return result, nil
+}
+
+// Purge deletes buckets which have not been used since the given time and returns the number of deleted buckets.
+func (r *RateLimitRepository) Purge(ctx context.Context, idleBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for key, bucket := range r.buckets {
+		if bucket.updatedAt.Before(idleBefore) {
+			delete(r.buckets, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"maps"
+	"slices"
+)
+
+// Batch applies the operations in order under one write lock. An operation failing with a record not found
+// or an edit conflict error keeps the error in its Err field. By default such a failure restores the state
+// before the batch and the remaining operations are not applied, with partial set the batch continues.
+func (r *SubscriptionRepository) Batch(ctx context.Context, operations []*models.BatchOperation, partial bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	actor := repository.Actor(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var restore func()
+	if !partial {
+		restore = r.snapshot()
+	}
+
+	for _, operation := range operations {
+		var err error
+
+		switch operation.Op {
+		case models.BatchOperationCreate:
+			err = r.insert(operation.Subscription, actor)
+		case models.BatchOperationUpdate:
+			err = r.update(operation.Subscription, actor)
+		default:
+			err = r.delete(operation.Subscription.ID, 0, actor)
+		}
+
+		if err != nil {
+			operation.Err = err
+			if !partial {
+				restore()
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// snapshot returns a function restoring the current state. Stored subscriptions and history records
+// are replaced rather than modified, so only the maps and the price lists are copied.
+// The caller must hold the write lock.
+func (r *SubscriptionRepository) snapshot() func() {
+	subscriptions := maps.Clone(r.subscriptions)
+	history := maps.Clone(r.history)
+	lastID, lastPriceID, lastHistoryID := r.lastID, r.lastPriceID, r.lastHistoryID
+
+	prices := make(map[int][]*models.SubscriptionPrice, len(r.prices))
+	for id, list := range r.prices {
+		prices[id] = slices.Clone(list)
+	}
+
+	return func() {
+		r.subscriptions, r.prices, r.history = subscriptions, prices, history
+		r.lastID, r.lastPriceID, r.lastHistoryID = lastID, lastPriceID, lastHistoryID
+	}
+}
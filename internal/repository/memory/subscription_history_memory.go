@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"time"
+)
+
+// GetHistory returns all recorded changes of the subscription ordered by version.
+// History is kept for deleted and purged subscriptions as well.
+func (r *SubscriptionRepository) GetHistory(ctx context.Context, subscriptionID int) ([]*models.SubscriptionHistory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := r.history[subscriptionID]
+	if len(records) == 0 {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	history := make([]*models.SubscriptionHistory, 0, len(records))
+	for _, record := range records {
+		history = append(history, copyHistory(record))
+	}
+
+	return history, nil
+}
+
+// GetVersion returns the subscription as it was right after the change with the given version.
+func (r *SubscriptionRepository) GetVersion(ctx context.Context, subscriptionID int, version int) (*models.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := r.history[subscriptionID]
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Version == version && records[i].NewValues != nil {
+			return copySubscription(records[i].NewValues), nil
+		}
+	}
+
+	return nil, repository.ErrRecordNotFound
+}
+
+// record appends a history record of the change from previous to current state of a subscription made by actor.
+// The caller must hold the write lock.
+func (r *SubscriptionRepository) record(operation string, actor string, previous, current *models.Subscription) {
+	r.lastHistoryID++
+
+	record := &models.SubscriptionHistory{
+		ID:            r.lastHistoryID,
+		Operation:     operation,
+		ChangedBy:     actor,
+		ChangedAt:     time.Now().UTC().Truncate(time.Second),
+		ChangedFields: []string{},
+	}
+
+	if previous != nil {
+		record.SubscriptionID = previous.ID
+		record.Version = previous.Version
+		record.OldValues = copySubscription(previous)
+	}
+	if current != nil {
+		record.SubscriptionID = current.ID
+		record.Version = current.Version
+		record.NewValues = copySubscription(current)
+	}
+	if previous != nil && current != nil {
+		record.ChangedFields = changedFields(previous, current)
+	}
+
+	r.history[record.SubscriptionID] = append(r.history[record.SubscriptionID], record)
+}
+
+// changedFields returns sorted JSON names of the fields which differ between a and b.
+func changedFields(a, b *models.Subscription) []string {
+	fields := []string{}
+
+	if a.BillingPeriod != b.BillingPeriod {
+		fields = append(fields, "billing_period")
+	}
+	if a.Currency != b.Currency {
+		fields = append(fields, "currency")
+	}
+	if !equalDates(a.EndDate, b.EndDate) {
+		fields = append(fields, "end_date")
+	}
+	if *a.Price != *b.Price {
+		fields = append(fields, "price")
+	}
+	if a.ServiceName != b.ServiceName {
+		fields = append(fields, "service_name")
+	}
+	if !a.StartDate.Time().Equal(b.StartDate.Time()) {
+		fields = append(fields, "start_date")
+	}
+	if a.UserID != b.UserID {
+		fields = append(fields, "user_id")
+	}
+
+	return fields
+}
+
+func equalDates(a, b *models.CustomDate) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Time().Equal(b.Time())
+}
+
+func copyHistory(record *models.SubscriptionHistory) *models.SubscriptionHistory {
+	c := *record
+	c.ChangedFields = append([]string{}, record.ChangedFields...)
+
+	if record.OldValues != nil {
+		c.OldValues = copySubscription(record.OldValues)
+	}
+	if record.NewValues != nil {
+		c.NewValues = copySubscription(record.NewValues)
+	}
+
+	return &c
+}
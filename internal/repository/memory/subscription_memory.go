@@ -0,0 +1,564 @@
+// Package memory implements storage in process memory. It is meant for local experiments
+// and tests and loses all data on restart.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"github.com/google/uuid"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SubscriptionRepository struct {
+	mu            sync.RWMutex
+	rates         *ExchangeRateRepository
+	subscriptions map[int]*models.Subscription
+	prices        map[int][]*models.SubscriptionPrice
+	history       map[int][]*models.SubscriptionHistory
+	lastID        int
+	lastPriceID   int
+	lastHistoryID int
+}
+
+func NewSubscriptionRepository(rates *ExchangeRateRepository) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		rates:         rates,
+		subscriptions: make(map[int]*models.Subscription),
+		prices:        make(map[int][]*models.SubscriptionPrice),
+		history:       make(map[int][]*models.SubscriptionHistory),
+	}
+}
+
+// Insert stores the subscription together with its initial price effective from start_date.
+func (r *SubscriptionRepository) Insert(ctx context.Context, subscription *models.Subscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.insert(subscription, repository.Actor(ctx))
+}
+
+// insert stores the subscription. The caller must hold the write lock.
+func (r *SubscriptionRepository) insert(subscription *models.Subscription, actor string) error {
+	r.lastID++
+	subscription.ID = r.lastID
+	subscription.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	subscription.Version = 1
+
+	stored := copySubscription(subscription)
+	stored.DeletedAt = nil
+	stored.Converted = nil
+	r.subscriptions[stored.ID] = stored
+
+	r.record(models.HistoryOperationInsert, actor, nil, stored)
+
+	r.insertPrice(&models.SubscriptionPrice{
+		SubscriptionID: stored.ID,
+		Price:          copyInt(stored.Price),
+		EffectiveDate:  stored.StartDate,
+	})
+
+	return nil
+}
+
+// InsertMany stores the subscriptions together with their initial prices.
+func (r *SubscriptionRepository) InsertMany(ctx context.Context, subscriptions []*models.Subscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	actor := repository.Actor(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, subscription := range subscriptions {
+		err := r.insert(subscription, actor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) Get(ctx context.Context, id int) (*models.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok || subscription.DeletedAt != nil {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	return copySubscription(subscription), nil
+}
+
+// Update saves the subscription if its version has not changed since it was read.
+// A changed price is recorded in the price history as effective from the current month.
+func (r *SubscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(subscription, repository.Actor(ctx))
+}
+
+// update saves the subscription if its version has not changed. The caller must hold the write lock.
+func (r *SubscriptionRepository) update(subscription *models.Subscription, actor string) error {
+	previous, ok := r.subscriptions[subscription.ID]
+	if !ok || previous.DeletedAt != nil || previous.Version != subscription.Version {
+		return repository.ErrEditConflict
+	}
+
+	stored := copySubscription(subscription)
+	stored.CreatedAt = previous.CreatedAt
+	stored.DeletedAt = nil
+	stored.Converted = nil
+	stored.Version = previous.Version + 1
+	r.subscriptions[stored.ID] = stored
+
+	subscription.Version = stored.Version
+
+	r.record(models.HistoryOperationUpdate, actor, previous, stored)
+
+	if *previous.Price != *stored.Price {
+		effectiveDate := models.CustomDate(monthStart(time.Now().UTC()))
+		if effectiveDate.Time().Before(stored.StartDate.Time()) {
+			effectiveDate = stored.StartDate
+		}
+
+		r.insertPrice(&models.SubscriptionPrice{
+			SubscriptionID: stored.ID,
+			Price:          copyInt(stored.Price),
+			EffectiveDate:  effectiveDate,
+		})
+	}
+
+	return nil
+}
+
+// Delete moves the subscription to the trash. It can be restored until it is purged. If version is not zero
+// the subscription is deleted only if it still has this version, otherwise ErrEditConflict is returned.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int, version int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(id, version, repository.Actor(ctx))
+}
+
+// delete moves the subscription to the trash. The caller must hold the write lock.
+func (r *SubscriptionRepository) delete(id int, version int, actor string) error {
+	previous, ok := r.subscriptions[id]
+
+	switch {
+	case (!ok || previous.DeletedAt != nil) && version != 0:
+		return repository.ErrEditConflict
+	case !ok || previous.DeletedAt != nil:
+		return repository.ErrRecordNotFound
+	case version != 0 && previous.Version != version:
+		return repository.ErrEditConflict
+	}
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+
+	stored := copySubscription(previous)
+	stored.DeletedAt = &deletedAt
+	stored.Version++
+	r.subscriptions[id] = stored
+
+	r.record(models.HistoryOperationDelete, actor, previous, stored)
+
+	return nil
+}
+
+// GetAll returns a page of subscriptions matching filter. If currency is not empty every subscription price is also
+// converted to it using the exchange rate effective in its billing month, like the same month of a sum.
+// A subscription without such a rate fails the request with repository.ErrExchangeRateNotFound.
+func (r *SubscriptionRepository) GetAll(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.Metadata{}, err
+	}
+
+	subscriptions, err := r.matching(filter, currency)
+	if err != nil {
+		return nil, models.Metadata{}, err
+	}
+	sortSubscriptions(subscriptions, filters)
+
+	if filters.Keyset() {
+		page, metadata := keysetPage(subscriptions, filters)
+		return page, metadata, nil
+	}
+
+	return paginate(subscriptions, filters)
+}
+
+// matching returns copies of the subscriptions matching filter in no particular order. If currency is not empty
+// every subscription price is also converted to it using the exchange rate effective in its billing month.
+func (r *SubscriptionRepository) matching(filter models.SubscriptionsFilter, currency string) ([]*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	queries := make([][]string, len(filter.ServiceNames))
+	for i, serviceName := range filter.ServiceNames {
+		queries[i] = repository.Lexemes(serviceName)
+	}
+	now := time.Now().UTC()
+
+	var subscriptions []*models.Subscription
+
+	for _, stored := range r.subscriptions {
+		if stored.DeletedAt != nil || !matchesFilter(stored, filter, queries) {
+			continue
+		}
+
+		subscription := copySubscription(stored)
+
+		if currency != "" {
+			rate, ok := r.rates.rate(subscription.Currency, currency, subscription.BillingMonth(now))
+			if !ok {
+				return nil, repository.ErrExchangeRateNotFound
+			}
+
+			subscription.Converted = &models.ConvertedPrice{
+				Price:    int(math.Round(float64(*subscription.Price) * rate)),
+				Currency: currency,
+			}
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// Export calls fn for every subscription matching filter in the sort order of filters, without pagination.
+// If currency is not empty every subscription price is also converted to it like in GetAll. A missing exchange rate
+// is reported with repository.ErrExchangeRateNotFound before fn is called.
+func (r *SubscriptionRepository) Export(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters, fn func(*models.Subscription) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	subscriptions, err := r.matching(filter, currency)
+	if err != nil {
+		return err
+	}
+	sortSubscriptions(subscriptions, filters)
+
+	for _, subscription := range subscriptions {
+		if err := fn(subscription); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// GetSubscriptionsSum charges every subscription for each month it is active within [beginDate, endDate]
+// with the price effective in that month normalized to a monthly cost and converted to currency
+// using the exchange rate effective on the first day of the month.
+func (r *SubscriptionRepository) GetSubscriptionsSum(ctx context.Context, userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate, currency string) (*models.SubscriptionsSum, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subscriptions []*models.Subscription
+	var firstMonth time.Time
+
+	for _, subscription := range r.subscriptions {
+		switch {
+		case subscription.DeletedAt != nil:
+			continue
+		case serviceName != "" && subscription.ServiceName != serviceName:
+			continue
+		case userID != uuid.Nil && subscription.UserID != userID:
+			continue
+		}
+
+		if firstMonth.IsZero() || subscription.StartDate.Time().Before(firstMonth) {
+			firstMonth = monthStart(subscription.StartDate.Time())
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	sum := &models.SubscriptionsSum{Currency: currency, Months: []models.MonthlySum{}}
+
+	if len(subscriptions) == 0 {
+		return sum, nil
+	}
+
+	if begin := monthStart(beginDate.Time()); begin.After(firstMonth) {
+		firstMonth = begin
+	}
+	lastMonth := monthStart(endDate.Time())
+
+	for month := firstMonth; !month.After(lastMonth); month = month.AddDate(0, 1, 0) {
+		var amount float64
+
+		for _, subscription := range subscriptions {
+			if subscription.StartDate.Time().After(month) {
+				continue
+			}
+			if subscription.EndDate != nil && subscription.EndDate.Time().Before(month) {
+				continue
+			}
+
+			rate, ok := r.rates.rate(subscription.Currency, currency, month)
+			if !ok {
+				return nil, repository.ErrExchangeRateNotFound
+			}
+
+			price := r.effectivePrice(subscription, month)
+			amount += float64(price) * models.MonthlyFactor(subscription.BillingPeriod) * rate
+		}
+
+		monthly := models.MonthlySum{Month: models.CustomDate(month), Sum: int(math.Round(amount))}
+		sum.Total += monthly.Sum
+		sum.Months = append(sum.Months, monthly)
+	}
+
+	return sum, nil
+}
+
+// effectivePrice returns the price of the subscription effective in month according to the price history.
+func (r *SubscriptionRepository) effectivePrice(subscription *models.Subscription, month time.Time) int {
+	price := *subscription.Price
+
+	for _, p := range r.prices[subscription.ID] {
+		if p.EffectiveDate.Time().After(month) {
+			break
+		}
+		price = *p.Price
+	}
+
+	return price
+}
+
+// matchesFilter reports whether the subscription matches filter the way repository.SubscriptionConditions does.
+// queries are the lexemes of filter.ServiceNames.
+func matchesFilter(subscription *models.Subscription, filter models.SubscriptionsFilter, queries [][]string) bool {
+	if len(queries) > 0 {
+		words := repository.Lexemes(subscription.ServiceName)
+		if !slices.ContainsFunc(queries, func(query []string) bool {
+			return repository.ContainsLexemes(words, query)
+		}) {
+			return false
+		}
+	}
+
+	if len(filter.UserIDs) > 0 && !slices.Contains(filter.UserIDs, subscription.UserID) {
+		return false
+	}
+
+	price := *subscription.Price
+	switch {
+	case filter.Price != -1 && price != filter.Price:
+		return false
+	case filter.PriceMin != -1 && price < filter.PriceMin:
+		return false
+	case filter.PriceMax != -1 && price > filter.PriceMax:
+		return false
+	}
+
+	start := subscription.StartDate.Time()
+	switch {
+	case filter.StartDate != models.CustomDate{} && !start.Equal(filter.StartDate.Time()):
+		return false
+	case filter.StartFrom != models.CustomDate{} && start.Before(filter.StartFrom.Time()):
+		return false
+	case filter.StartTo != models.CustomDate{} && start.After(filter.StartTo.Time()):
+		return false
+	}
+
+	end := subscription.EndDate
+	switch {
+	case filter.EndFrom != models.CustomDate{} && (end == nil || end.Time().Before(filter.EndFrom.Time())):
+		return false
+	case filter.EndTo != models.CustomDate{} && (end == nil || end.Time().After(filter.EndTo.Time())):
+		return false
+	case filter.HasEndDate != nil && *filter.HasEndDate != (end != nil):
+		return false
+	}
+
+	if filter.ActiveOn != (models.CustomDate{}) {
+		month := filter.ActiveOn.Time()
+		if start.After(month) || (end != nil && end.Time().Before(month)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortSubscriptions(subscriptions []*models.Subscription, filters models.Filters) {
+	keys := filters.SortKeys()
+
+	slices.SortFunc(subscriptions, func(a, b *models.Subscription) int {
+		for _, key := range keys {
+			c := compareColumn(a, b, key.Column)
+			if key.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+
+		return 0
+	})
+}
+
+func compareColumn(a, b *models.Subscription, column string) int {
+	switch column {
+	case "service_name":
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	case "price":
+		return cmp.Compare(*a.Price, *b.Price)
+	case "start_date":
+		return a.StartDate.Time().Compare(b.StartDate.Time())
+	case "deleted_at":
+		return compareTimes(a.DeletedAt, b.DeletedAt)
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+// compareCursor compares the subscription with the cursor position in the order of keys.
+func compareCursor(subscription *models.Subscription, keys []models.SortKey, values []string) int {
+	for i, key := range keys {
+		var c int
+
+		switch key.Column {
+		case "service_name":
+			c = strings.Compare(subscription.ServiceName, values[i])
+		case "start_date":
+			date, _ := time.Parse(time.DateOnly, values[i])
+			c = subscription.StartDate.Time().Compare(date)
+		case "deleted_at":
+			deletedAt, _ := time.Parse(time.RFC3339Nano, values[i])
+			c = compareTimes(subscription.DeletedAt, &deletedAt)
+		case "price":
+			price, _ := strconv.Atoi(values[i])
+			c = cmp.Compare(*subscription.Price, price)
+		default:
+			id, _ := strconv.Atoi(values[i])
+			c = cmp.Compare(subscription.ID, id)
+		}
+
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// keysetPage selects the page following or preceding the cursor from sorted subscriptions.
+func keysetPage(subscriptions []*models.Subscription, filters models.Filters) ([]*models.Subscription, models.Metadata) {
+	cursor, backward := filters.Cursor()
+	keys := filters.SortKeys()
+
+	var selected []*models.Subscription
+	for _, subscription := range subscriptions {
+		c := compareCursor(subscription, keys, cursor.Values)
+		if (c > 0 && !backward) || (c < 0 && backward) {
+			selected = append(selected, subscription)
+		}
+	}
+
+	if backward {
+		slices.Reverse(selected)
+	}
+
+	return repository.KeysetPage(selected[:min(len(selected), filters.Limit()+1)], filters)
+}
+
+func paginate(subscriptions []*models.Subscription, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	totalRecords := len(subscriptions)
+
+	start := min(filters.Offset(), totalRecords)
+	end := min(start+filters.Limit(), totalRecords)
+
+	page := subscriptions[start:end]
+	if len(page) == 0 {
+		return nil, models.Metadata{}, nil
+	}
+
+	return page, models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+func compareTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func copyInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+
+	c := *i
+	return &c
+}
+
+func copySubscription(subscription *models.Subscription) *models.Subscription {
+	c := *subscription
+	c.Price = copyInt(subscription.Price)
+
+	if subscription.EndDate != nil {
+		endDate := *subscription.EndDate
+		c.EndDate = &endDate
+	}
+	if subscription.DeletedAt != nil {
+		deletedAt := *subscription.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	if subscription.Converted != nil {
+		converted := *subscription.Converted
+		c.Converted = &converted
+	}
+
+	return &c
+}
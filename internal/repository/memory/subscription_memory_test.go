@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"eff-subscriptions/internal/repository/memory"
+	"eff-subscriptions/internal/repository/repotest"
+	"eff-subscriptions/internal/service"
+	"testing"
+)
+
+func TestSubscriptionRepository(t *testing.T) {
+	repotest.SubscriptionRepository(t, func(t *testing.T) service.SubscriptionProvider {
+		return memory.NewSubscriptionRepository(memory.NewExchangeRateRepository())
+	})
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"slices"
+	"time"
+)
+
+// InsertPrice records a price change of the subscription. A price already recorded for the same
+// month is replaced. Unless the new price starts in the future, the subscription price is set to
+// the latest price effective today and its version is incremented.
+func (r *SubscriptionRepository) InsertPrice(ctx context.Context, price *models.SubscriptionPrice) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.subscriptions[price.SubscriptionID]
+	if !ok || previous.DeletedAt != nil {
+		return repository.ErrRecordNotFound
+	}
+
+	r.insertPrice(price)
+
+	today := time.Now().UTC()
+	if price.EffectiveDate.Time().After(today) {
+		return nil
+	}
+
+	current := r.effectivePrice(previous, today)
+
+	stored := copySubscription(previous)
+	stored.Price = &current
+	stored.Version++
+	r.subscriptions[stored.ID] = stored
+
+	r.record(models.HistoryOperationUpdate, repository.Actor(ctx), previous, stored)
+
+	return nil
+}
+
+func (r *SubscriptionRepository) GetPrices(ctx context.Context, subscriptionID int) ([]*models.SubscriptionPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prices := []*models.SubscriptionPrice{}
+
+	for _, price := range r.prices[subscriptionID] {
+		prices = append(prices, copyPrice(price))
+	}
+
+	return prices, nil
+}
+
+// insertPrice upserts the price effective from price.EffectiveDate keeping prices sorted by effective date.
+// The caller must hold the write lock.
+func (r *SubscriptionRepository) insertPrice(price *models.SubscriptionPrice) {
+	prices := r.prices[price.SubscriptionID]
+
+	i, found := slices.BinarySearchFunc(prices, price.EffectiveDate.Time(), func(p *models.SubscriptionPrice, date time.Time) int {
+		return p.EffectiveDate.Time().Compare(date)
+	})
+	if found {
+		price.ID = prices[i].ID
+		price.CreatedAt = prices[i].CreatedAt
+		prices[i] = copyPrice(price)
+		return
+	}
+
+	r.lastPriceID++
+	price.ID = r.lastPriceID
+	price.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	r.prices[price.SubscriptionID] = slices.Insert(prices, i, copyPrice(price))
+}
+
+func copyPrice(price *models.SubscriptionPrice) *models.SubscriptionPrice {
+	c := *price
+	c.Price = copyInt(price.Price)
+
+	return &c
+}
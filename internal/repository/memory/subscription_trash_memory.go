@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"time"
+)
+
+// GetTrash returns a page of deleted subscriptions that have not been purged yet.
+func (r *SubscriptionRepository) GetTrash(ctx context.Context, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, models.Metadata{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subscriptions []*models.Subscription
+
+	for _, subscription := range r.subscriptions {
+		if subscription.DeletedAt != nil {
+			subscriptions = append(subscriptions, copySubscription(subscription))
+		}
+	}
+
+	sortSubscriptions(subscriptions, filters)
+
+	return paginate(subscriptions, filters)
+}
+
+// Restore takes the subscription out of the trash.
+func (r *SubscriptionRepository) Restore(ctx context.Context, id int) (*models.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.subscriptions[id]
+	if !ok || previous.DeletedAt == nil {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	stored := copySubscription(previous)
+	stored.DeletedAt = nil
+	stored.Version++
+	r.subscriptions[id] = stored
+
+	r.record(models.HistoryOperationRestore, repository.Actor(ctx), previous, stored)
+
+	return copySubscription(stored), nil
+}
+
+// Purge permanently deletes subscriptions moved to the trash before the given time
+// and returns the number of deleted subscriptions.
+func (r *SubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	actor := repository.Actor(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+
+	for id, subscription := range r.subscriptions {
+		if subscription.DeletedAt == nil || !subscription.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		delete(r.subscriptions, id)
+		delete(r.prices, id)
+
+		r.record(models.HistoryOperationPurge, actor, subscription, nil)
+
+		purged++
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+	"github.com/lib/pq"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewAPIKeyRepository(db *sql.DB, timeouts config.QueryTimeouts) *APIKeyRepository {
+	return &APIKeyRepository{db: db, timeouts: timeouts}
+}
+
+func (r *APIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+
+	return repository.ContextError(ctx, err)
+}
+
+// GetAll returns all keys including revoked and expired ones.
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY id;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+
+	for rows.Next() {
+		var key models.APIKey
+
+		err = rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			pq.Array(&key.Scopes),
+			&key.CreatedAt,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		keys = append(keys, &key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	return keys, nil
+}
+
+// GetByHash returns the key with the hash unless it has been revoked.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*models.APIKey, error) {
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var key models.APIKey
+
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		pq.Array(&key.Scopes),
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrRecordNotFound
+		default:
+			return nil, repository.ContextError(ctx, err)
+		}
+	}
+
+	return &key, nil
+}
+
+// Revoke marks the key as revoked, it cannot be used afterwards.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Touch records when the key was last used.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, id, usedAt)
+
+	return repository.ContextError(ctx, err)
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+	"time"
+)
+
+type IdempotencyKeyRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewIdempotencyKeyRepository(db *sql.DB, timeouts config.QueryTimeouts) *IdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: db, timeouts: timeouts}
+}
+
+// Reserve stores the key unless a key with the same name exists and has not expired by key.CreatedAt.
+// A key still being processed which was reserved at or before staleBefore is replaced as well.
+// It returns nil if the key was stored and the existing key otherwise.
+func (r *IdempotencyKeyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey, staleBefore time.Time) (*models.IdempotencyKey, error) {
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = NULL, response = NULL,
+		    created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		   OR (idempotency_keys.status IS NULL AND idempotency_keys.created_at <= $5)
+		RETURNING key;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var reserved string
+
+	err = tx.QueryRowContext(ctx, query, key.Key, key.RequestHash, key.CreatedAt, key.ExpiresAt, staleBefore).Scan(&reserved)
+	if err == nil {
+		return nil, repository.ContextError(ctx, tx.Commit())
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	// ON CONFLICT locks the existing row, so it cannot be released before it is read.
+	existing := models.IdempotencyKey{Key: key.Key}
+	var status sql.NullInt64
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT request_hash, status, response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1;`, key.Key).Scan(
+		&existing.RequestHash,
+		&status,
+		&existing.Response,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	existing.Status = int(status.Int64)
+
+	return &existing, repository.ContextError(ctx, tx.Commit())
+}
+
+// Complete stores the response of the request reserved by the key.
+func (r *IdempotencyKeyRepository) Complete(ctx context.Context, key string, status int, response []byte) error {
+	query := `UPDATE idempotency_keys SET status = $2, response = $3 WHERE key = $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, key, status, response)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete releases the key so that the request can be retried.
+func (r *IdempotencyKeyRepository) Delete(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE key = $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, key)
+
+	return repository.ContextError(ctx, err)
+}
+
+// Purge deletes keys which expired before the given time and returns the number of deleted keys.
+func (r *IdempotencyKeyRepository) Purge(ctx context.Context, expiredBefore time.Time) (int, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at < $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, expiredBefore)
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
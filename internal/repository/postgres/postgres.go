@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/repository"
 	"fmt"
 	_ "github.com/lib/pq"
 	"time"
@@ -30,3 +31,20 @@ func NewPostgresDB(cfg config.DBConfig) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// beginWrite starts a transaction changing subscriptions. The actor from ctx is stored in the
+// transaction-local app.actor setting, the history trigger records it as the author of the changes.
+func beginWrite(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `SELECT set_config('app.actor', $1, true);`, repository.Actor(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"time"
+)
+
+// RateLimitRepository keeps token buckets in the database, so that instances of the server share the limits.
+type RateLimitRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewRateLimitRepository(db *sql.DB, timeouts config.QueryTimeouts) *RateLimitRepository {
+	return &RateLimitRepository{db: db, timeouts: timeouts}
+}
+
+// Take refills the bucket with the key and takes a token from it if there is one. A missing bucket is full.
+func (r *RateLimitRepository) Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.RateLimitResult{}, repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING;`, key, limit.Burst, now)
+	if err != nil {
+		return models.RateLimitResult{}, repository.ContextError(ctx, err)
+	}
+
+	// The bucket stays locked until the transaction ends, so concurrent requests cannot take the same token.
+	var tokens float64
+	var updatedAt time.Time
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, updated_at
+		FROM rate_limit_buckets
+		WHERE key = $1
+		FOR UPDATE;`, key).Scan(&tokens, &updatedAt)
+	if err != nil {
+		return models.RateLimitResult{}, repository.ContextError(ctx, err)
+	}
+
+	result := limit.Take(limit.Refill(tokens, now.Sub(updatedAt)))
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_limit_buckets
+		SET tokens = $2, updated_at = GREATEST(updated_at, $3)
+		WHERE key = $1;`, key, result.Tokens, now)
+	if err != nil {
+		return models.RateLimitResult{}, repository.ContextError(ctx, err)
+	}
+
+	return result, repository.ContextError(ctx, tx.Commit())
+}
+
+// Purge deletes buckets which have not been used since the given time and returns the number of deleted buckets.
+func (r *RateLimitRepository) Purge(ctx context.Context, idleBefore time.Time) (int, error) {
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, idleBefore)
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
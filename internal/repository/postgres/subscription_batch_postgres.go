@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+)
+
+// Batch applies the operations in order in one transaction. An operation failing with a record not found
+// or an edit conflict error keeps the error in its Err field. By default such a failure rolls the whole
+// batch back and the remaining operations are not applied, with partial set only the failed operation
+// is rolled back to its savepoint and the batch continues.
+func (r *SubscriptionRepository) Batch(ctx context.Context, operations []*models.BatchOperation, partial bool) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	for _, operation := range operations {
+		if partial {
+			_, err = tx.ExecContext(ctx, `SAVEPOINT batch_operation;`)
+			if err != nil {
+				return repository.ContextError(ctx, err)
+			}
+		}
+
+		err = applyOperation(ctx, tx, operation)
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound) || errors.Is(err, repository.ErrEditConflict):
+			operation.Err = err
+			if !partial {
+				return nil
+			}
+
+			_, err = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_operation;`)
+		}
+		if err == nil && partial {
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_operation;`)
+		}
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+func applyOperation(ctx context.Context, tx *sql.Tx, operation *models.BatchOperation) error {
+	switch operation.Op {
+	case models.BatchOperationCreate:
+		return insertSubscription(ctx, tx, operation.Subscription)
+	case models.BatchOperationUpdate:
+		return updateSubscription(ctx, tx, operation.Subscription)
+	default:
+		return deleteSubscription(ctx, tx, operation.Subscription.ID, 0)
+	}
+}
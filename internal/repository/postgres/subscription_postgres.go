@@ -3,60 +3,128 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"eff-subscriptions/internal/config"
 	"eff-subscriptions/internal/domain/models"
 	"eff-subscriptions/internal/repository"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"time"
 )
 
 type SubscriptionRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts config.QueryTimeouts
 }
 
-func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
-	return &SubscriptionRepository{db: db}
+func NewSubscriptionRepository(db *sql.DB, timeouts config.QueryTimeouts) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db, timeouts: timeouts}
 }
 
-func (r *SubscriptionRepository) Insert(subscription *models.Subscription) error {
-	query := `
-		INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date) 
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, version;`
+// Insert stores the subscription together with its initial price effective from start_date.
+func (r *SubscriptionRepository) Insert(ctx context.Context, subscription *models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
 
-	args := []any{subscription.ServiceName, subscription.Price, subscription.UserID, subscription.StartDate.Time()}
-	if subscription.EndDate != nil {
-		args = append(args, subscription.EndDate.Time())
-	} else {
-		args = append(args, nil)
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
 	}
+	defer tx.Rollback()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	err = insertSubscription(ctx, tx, subscription)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// InsertMany stores the subscriptions together with their initial prices in a single transaction.
+// Rows are loaded with COPY into a temporary table and moved to subscriptions by one statement.
+func (r *SubscriptionRepository) InsertMany(ctx context.Context, subscriptions []*models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
 	defer cancel()
 
-	return r.db.QueryRowContext(ctx, query, args...).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.Version)
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TEMPORARY TABLE subscriptions_import (
+			service_name TEXT, price INTEGER, currency TEXT, billing_period TEXT, user_id UUID, start_date DATE, end_date DATE
+		) ON COMMIT DROP;`)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("subscriptions_import",
+		"service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date"))
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer stmt.Close()
+
+	for _, subscription := range subscriptions {
+		var endDate any
+		if subscription.EndDate != nil {
+			endDate = subscription.EndDate.Time()
+		}
+
+		_, err = stmt.ExecContext(ctx, subscription.ServiceName, subscription.Price, subscription.Currency,
+			subscription.BillingPeriod, subscription.UserID, subscription.StartDate.Time(), endDate)
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+	}
+
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO subscriptions(service_name, price, currency, billing_period, user_id, start_date, end_date)
+			SELECT service_name, price, currency, billing_period, user_id, start_date, end_date
+			FROM subscriptions_import
+			RETURNING id, price, start_date
+		)
+		INSERT INTO subscription_prices(subscription_id, price, effective_date)
+		SELECT id, price, start_date FROM inserted;`
+
+	_, err = tx.ExecContext(ctx, query)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
 }
 
-func (r *SubscriptionRepository) Get(id int) (*models.Subscription, error) {
+func (r *SubscriptionRepository) Get(ctx context.Context, id int) (*models.Subscription, error) {
 	if id < 1 {
 		return nil, repository.ErrRecordNotFound
 	}
 
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, version
+		SELECT id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version
 		FROM subscriptions
-		WHERE id = $1;`
+		WHERE id = $1 AND deleted_at IS NULL;`
 
 	var subscription models.Subscription
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
 	defer cancel()
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&subscription.ID,
 		&subscription.ServiceName,
 		&subscription.Price,
+		&subscription.Currency,
+		&subscription.BillingPeriod,
 		&subscription.UserID,
 		&subscription.StartDate,
 		&subscription.EndDate,
@@ -67,23 +135,98 @@ func (r *SubscriptionRepository) Get(id int) (*models.Subscription, error) {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, repository.ErrRecordNotFound
 		default:
-			return nil, err
+			return nil, repository.ContextError(ctx, err)
 		}
 	}
 
 	return &subscription, nil
 }
 
-func (r *SubscriptionRepository) Update(subscription *models.Subscription) error {
+// Update saves the subscription if its version has not changed since it was read.
+// A changed price is recorded in the price history as effective from the current month,
+// so costs of the previous months are not affected.
+func (r *SubscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = updateSubscription(ctx, tx, subscription)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// Delete moves the subscription to the trash. It can be restored until it is purged. If version is not zero
+// the subscription is deleted only if it still has this version, otherwise ErrEditConflict is returned.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = deleteSubscription(ctx, tx, id, version)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// insertSubscription stores the subscription together with its initial price inside tx.
+func insertSubscription(ctx context.Context, tx *sql.Tx, subscription *models.Subscription) error {
 	query := `
+		INSERT INTO subscriptions(service_name, price, currency, billing_period, user_id, start_date, end_date) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, version;`
+
+	args := []any{subscription.ServiceName, subscription.Price, subscription.Currency, subscription.BillingPeriod, subscription.UserID, subscription.StartDate.Time()}
+	if subscription.EndDate != nil {
+		args = append(args, subscription.EndDate.Time())
+	} else {
+		args = append(args, nil)
+	}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.Version)
+	if err != nil {
+		return err
+	}
+
+	return insertPrice(ctx, tx, &models.SubscriptionPrice{
+		SubscriptionID: subscription.ID,
+		Price:          subscription.Price,
+		EffectiveDate:  subscription.StartDate,
+	})
+}
+
+// updateSubscription saves the subscription inside tx if its version has not changed since it was read.
+func updateSubscription(ctx context.Context, tx *sql.Tx, subscription *models.Subscription) error {
+	query := `
+		WITH previous AS (
+			SELECT price FROM subscriptions WHERE id = $8
+		)
 		UPDATE subscriptions
-		SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5, version = version + 1
-		WHERE id = $6 AND version = $7
-		RETURNING version;`
+		SET service_name = $1, price = $2, currency = $3, billing_period = $4, user_id = $5, start_date = $6, end_date = $7,
+			version = version + 1
+		FROM previous
+		WHERE id = $8 AND version = $9 AND deleted_at IS NULL
+		RETURNING version, previous.price;`
 
 	args := []any{
 		subscription.ServiceName,
 		subscription.Price,
+		subscription.Currency,
+		subscription.BillingPeriod,
 		subscription.UserID,
 		subscription.StartDate.Time(),
 		nil,
@@ -91,13 +234,11 @@ func (r *SubscriptionRepository) Update(subscription *models.Subscription) error
 		subscription.Version}
 
 	if subscription.EndDate != nil {
-		args[4] = subscription.EndDate.Time()
+		args[6] = subscription.EndDate.Time()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&subscription.Version)
+	var previousPrice int
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&subscription.Version, &previousPrice)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -107,16 +248,31 @@ func (r *SubscriptionRepository) Update(subscription *models.Subscription) error
 		}
 	}
 
+	if previousPrice != *subscription.Price {
+		now := time.Now().UTC()
+		effectiveDate := models.CustomDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		if effectiveDate.Time().Before(subscription.StartDate.Time()) {
+			effectiveDate = subscription.StartDate
+		}
+
+		return insertPrice(ctx, tx, &models.SubscriptionPrice{
+			SubscriptionID: subscription.ID,
+			Price:          subscription.Price,
+			EffectiveDate:  effectiveDate,
+		})
+	}
+
 	return nil
 }
 
-func (r *SubscriptionRepository) Delete(id int) error {
-	query := `DELETE FROM subscriptions WHERE id = $1;`
-
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// deleteSubscription moves the subscription to the trash inside tx.
+func deleteSubscription(ctx context.Context, tx *sql.Tx, id int, version int) error {
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2);`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := tx.ExecContext(ctx, query, id, version)
 	if err != nil {
 		return err
 	}
@@ -126,32 +282,52 @@ func (r *SubscriptionRepository) Delete(id int) error {
 		return err
 	}
 
-	if rowsAffected == 0 {
+	switch {
+	case rowsAffected == 0 && version != 0:
+		return repository.ErrEditConflict
+	case rowsAffected == 0:
 		return repository.ErrRecordNotFound
 	}
 
 	return nil
 }
 
-func (r *SubscriptionRepository) GetAll(serviceName string, price int, userID uuid.UUID, startDate models.CustomDate, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+// GetAll returns a page of subscriptions matching filter. If currency is not empty every subscription price is also
+// converted to it using the exchange rate effective in its billing month, like the same month of a sum.
+// A subscription without such a rate fails the request with repository.ErrExchangeRateNotFound.
+func (r *SubscriptionRepository) GetAll(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	conditions := subscriptionConditions(filter)
+
+	// Keyset pages are read one row past the page size to find out whether more rows follow.
+	// They are not counted, since a window count has to scan every matching row.
+	count, limit, offset := "COUNT(*) OVER ()", filters.Limit(), filters.Offset()
+	cursor, backward := filters.Cursor()
+	if cursor != nil {
+		count, limit, offset = "0", filters.Limit()+1, 0
+
+		keyset, args := repository.KeysetSQL(filters.SortKeys(), backward, cursor.Values)
+		conditions.Add(keyset, args...)
+	}
+
+	where := conditions.SQL()
+	rate := exchangeRateSQL("currency", conditions.Arg(currency), billingMonthSQL)
+
 	query := fmt.Sprintf(`
-		SELECT COUNT(*) OVER (), id, service_name, price, user_id, start_date, end_date, created_at, version
+		SELECT %s, id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version,
+			ROUND(price * %s)::bigint
 		FROM subscriptions
-		WHERE (to_tsvector('simple', service_name) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		AND (price = $2 OR $2 = -1)
-		AND (user_id = $3 OR $3 = '00000000-0000-0000-0000-000000000000')
-		AND (start_date = $4 OR $4 = '01-01-0001')
-		ORDER BY %s %s, id ASC
-		LIMIT $5 OFFSET $6`, filters.SortColumn(), filters.SortDirection())
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, count, rate, where, repository.OrderBySQL(filters.SortKeys(), backward), conditions.Arg(limit), conditions.Arg(offset))
 
-	args := []any{serviceName, price, userID, startDate.Time(), filters.Limit(), filters.Offset()}
+	args := conditions.Args()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
 	defer cancel()
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, models.Metadata{}, err
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
 	}
 	defer rows.Close()
 
@@ -160,26 +336,42 @@ func (r *SubscriptionRepository) GetAll(serviceName string, price int, userID uu
 
 	for rows.Next() {
 		var subscription models.Subscription
+		var convertedPrice *int
 		err := rows.Scan(
 			&totalRecords,
 			&subscription.ID,
 			&subscription.ServiceName,
 			&subscription.Price,
+			&subscription.Currency,
+			&subscription.BillingPeriod,
 			&subscription.UserID,
 			&subscription.StartDate,
 			&subscription.EndDate,
 			&subscription.CreatedAt,
 			&subscription.Version,
+			&convertedPrice,
 		)
 		if err != nil {
-			return nil, models.Metadata{}, err
+			return nil, models.Metadata{}, repository.ContextError(ctx, err)
+		}
+
+		if currency != "" {
+			if convertedPrice == nil {
+				return nil, models.Metadata{}, repository.ErrExchangeRateNotFound
+			}
+			subscription.Converted = &models.ConvertedPrice{Price: *convertedPrice, Currency: currency}
 		}
 
 		subscriptions = append(subscriptions, &subscription)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, models.Metadata{}, err
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
+	}
+
+	if cursor != nil {
+		subscriptions, metadata := repository.KeysetPage(subscriptions, filters)
+		return subscriptions, metadata, nil
 	}
 
 	metadata := models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
@@ -187,27 +379,124 @@ func (r *SubscriptionRepository) GetAll(serviceName string, price int, userID uu
 	return subscriptions, metadata, nil
 }
 
-func (r *SubscriptionRepository) GetSubscriptionsSum(userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate) (int, error) {
-	query := `
- 		SELECT SUM(price)
-		FROM subscriptions
-		WHERE start_date >= $1 AND start_date <= $2
-			AND (service_name = $3 OR $3 = '')
-			AND (user_id = $4 OR $4 = '00000000-0000-0000-0000-000000000000')`
+// subscriptionConditions returns the conditions selecting subscriptions matching filter.
+func subscriptionConditions(filter models.SubscriptionsFilter) *repository.Conditions {
+	return repository.SubscriptionConditions(filter, "to_tsvector('simple', service_name) @@ plainto_tsquery('simple', ?)", func(t time.Time) any { return t })
+}
+
+// GetSubscriptionsSum charges every subscription for each month it is active within [beginDate, endDate].
+// A subscription is active from its start_date month up to and including its end_date month,
+// open-ended subscriptions are active until the end of the period. Every month is charged with the
+// price effective in that month according to the price history. Prices are normalized to
+// a monthly cost according to the billing period, so a yearly 12000 subscription costs 1000 a month,
+// and converted to currency using the exchange rate effective on the first day of each month.
+func (r *SubscriptionRepository) GetSubscriptionsSum(ctx context.Context, userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate, currency string) (*models.SubscriptionsSum, error) {
+	query := fmt.Sprintf(`
+		WITH filtered AS (
+			SELECT id, price, currency, billing_period, start_date, end_date
+			FROM subscriptions
+			WHERE deleted_at IS NULL
+				AND (service_name = $3 OR $3 = '')
+				AND (user_id = $4 OR $4 = '00000000-0000-0000-0000-000000000000')
+		), bounds AS (
+			SELECT GREATEST(date_trunc('month', $1::date), date_trunc('month', MIN(start_date))) AS first_month
+			FROM filtered
+			HAVING COUNT(*) > 0
+		), months AS (
+			SELECT generate_series(first_month, date_trunc('month', $2::date), interval '1 month')::date AS month
+			FROM bounds
+		), charges AS (
+			SELECT m.month, f.currency, COALESCE(%s, f.price) * %s AS monthly_price
+			FROM months m
+			LEFT JOIN filtered f ON f.start_date <= m.month AND (f.end_date IS NULL OR f.end_date >= m.month)
+		), converted AS (
+			SELECT c.month, c.monthly_price, c.monthly_price * %s AS amount
+			FROM charges c
+		)
+		SELECT month, COALESCE(ROUND(SUM(amount)), 0)::bigint,
+			COUNT(*) FILTER (WHERE monthly_price IS NOT NULL AND amount IS NULL)
+		FROM converted
+		GROUP BY month
+		ORDER BY month;`,
+		effectivePriceSQL("f.id", "m.month"), monthlyFactorSQL("f.billing_period"), exchangeRateSQL("c.currency", "$5", "c.month"))
 
-	args := []any{beginDate.Time(), endDate.Time(), serviceName, userID}
+	args := []any{beginDate.Time(), endDate.Time(), serviceName, userID, currency}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Report)
 	defer cancel()
 
-	var sum *int
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&sum)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return 0, err
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	sum := &models.SubscriptionsSum{Currency: currency, Months: []models.MonthlySum{}}
+
+	for rows.Next() {
+		var month models.MonthlySum
+		var unconverted int
+		err := rows.Scan(&month.Month, &month.Sum, &unconverted)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		if unconverted > 0 {
+			return nil, repository.ErrExchangeRateNotFound
+		}
+
+		sum.Total += month.Sum
+		sum.Months = append(sum.Months, month)
 	}
 
-	if sum == nil {
-		return 0, nil
+	if err := rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
 	}
-	return *sum, nil
+
+	return sum, nil
+}
+
+// monthlyFactorSQL returns an SQL expression that converts a price billed with the period stored
+// in column to the equivalent monthly cost.
+func monthlyFactorSQL(column string) string {
+	return fmt.Sprintf(`(CASE %s
+		WHEN 'weekly' THEN 52.0 / 12
+		WHEN 'quarterly' THEN 1.0 / 3
+		WHEN 'yearly' THEN 1.0 / 12
+		ELSE 1 END)`, column)
+}
+
+// effectivePriceSQL returns an SQL expression that evaluates to the price of subscription effective
+// on date according to the price history. The expression is NULL when the history has no such price.
+func effectivePriceSQL(subscriptionID, date string) string {
+	return fmt.Sprintf(`(
+		SELECT p.price
+		FROM subscription_prices p
+		WHERE p.subscription_id = %s AND p.effective_date <= %s
+		ORDER BY p.effective_date DESC
+		LIMIT 1)`, subscriptionID, date)
+}
+
+// billingMonthSQL an SQL expression that evaluates to the month the current price of a subscription is charged
+// for, see models.Subscription.BillingMonth.
+const billingMonthSQL = `(CASE
+		WHEN start_date > date_trunc('month', CURRENT_DATE)::date THEN start_date
+		WHEN end_date < date_trunc('month', CURRENT_DATE)::date THEN end_date
+		ELSE date_trunc('month', CURRENT_DATE)::date END)`
+
+// exchangeRateSQL returns an SQL expression that evaluates to the rate converting currencyColumn
+// to target currency effective on date. Rates stored in the opposite direction are inverted.
+// The expression is NULL when no suitable rate exists.
+func exchangeRateSQL(currencyColumn, target, date string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s = %[2]s THEN 1 ELSE (
+		SELECT r.rate
+		FROM (
+			SELECT rate, effective_date FROM exchange_rates
+			WHERE base_currency = %[1]s AND quote_currency = %[2]s AND effective_date <= %[3]s
+			UNION ALL
+			SELECT 1 / rate, effective_date FROM exchange_rates
+			WHERE base_currency = %[2]s AND quote_currency = %[1]s AND effective_date <= %[3]s
+		) r
+		ORDER BY r.effective_date DESC
+		LIMIT 1) END)`, currencyColumn, target, date)
 }
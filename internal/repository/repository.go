@@ -1,8 +1,24 @@
 package repository
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
+
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
 )
+
+// ContextError returns the error of ctx if it is done, so that an operation interrupted by
+// cancellation or timeout is reported with context.Canceled or context.DeadlineExceeded
+// whatever error the database driver returned.
+func ContextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
@@ -0,0 +1,428 @@
+// Package repotest is the conformance suite every storage implementation of service.SubscriptionProvider
+// must pass. Storage packages run it from their tests with a constructor of empty repositories.
+package repotest
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"eff-subscriptions/internal/service"
+	"errors"
+	"github.com/google/uuid"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sortSafelist sort values accepted by the subscriptions list.
+var sortSafelist = []string{"id", "service_name", "price", "start_date", "-id", "-service_name", "-price", "-start_date"}
+
+// SubscriptionRepository runs the conformance suite. newRepository must return an empty repository,
+// it is called once for every subtest.
+func SubscriptionRepository(t *testing.T, newRepository func(t *testing.T) service.SubscriptionProvider) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, r service.SubscriptionProvider)
+	}{
+		{"InsertAndGet", testInsertAndGet},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"GetAllFilters", testGetAllFilters},
+		{"GetAllSort", testGetAllSort},
+		{"GetAllPages", testGetAllPages},
+		{"GetAllCursor", testGetAllCursor},
+		{"GetSubscriptionsSum", testGetSubscriptionsSum},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepository(t))
+		})
+	}
+}
+
+func testInsertAndGet(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+
+	end := month("2025-12")
+	subscription := newSubscription("Yandex Plus", 400, uuid.New(), "2025-07")
+	subscription.EndDate = &end
+
+	mustInsert(t, r, subscription)
+
+	if subscription.ID == 0 || subscription.Version != 1 {
+		t.Fatalf("Insert: got id %d and version %d, want a new id and version 1", subscription.ID, subscription.Version)
+	}
+
+	got, err := r.Get(ctx, subscription.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertSubscription(t, got, subscription)
+
+	_, err = r.Get(ctx, subscription.ID+1)
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		t.Fatalf("Get of a missing subscription: got %v, want %v", err, repository.ErrRecordNotFound)
+	}
+}
+
+func testUpdate(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+
+	subscription := newSubscription("Yandex Plus", 400, uuid.New(), "2025-07")
+	mustInsert(t, r, subscription)
+
+	stale := *subscription
+
+	subscription.ServiceName = "Yandex Plus Family"
+	err := r.Update(ctx, subscription)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if subscription.Version != 2 {
+		t.Fatalf("Update: got version %d, want 2", subscription.Version)
+	}
+
+	got, err := r.Get(ctx, subscription.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertSubscription(t, got, subscription)
+
+	stale.ServiceName = "Yandex Music"
+	err = r.Update(ctx, &stale)
+	if !errors.Is(err, repository.ErrEditConflict) {
+		t.Fatalf("Update of a stale version: got %v, want %v", err, repository.ErrEditConflict)
+	}
+
+	got, err = r.Get(ctx, subscription.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertSubscription(t, got, subscription)
+}
+
+func testDelete(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+
+	deleted := newSubscription("Yandex Plus", 400, uuid.New(), "2025-07")
+	kept := newSubscription("Netflix", 900, uuid.New(), "2025-01")
+	mustInsert(t, r, deleted, kept)
+
+	err := r.Delete(ctx, kept.ID, kept.Version+1)
+	if !errors.Is(err, repository.ErrEditConflict) {
+		t.Fatalf("Delete of a stale version: got %v, want %v", err, repository.ErrEditConflict)
+	}
+
+	err = r.Delete(ctx, deleted.ID, deleted.Version)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, err = r.Get(ctx, deleted.ID)
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		t.Fatalf("Get of a deleted subscription: got %v, want %v", err, repository.ErrRecordNotFound)
+	}
+
+	err = r.Delete(ctx, deleted.ID, 0)
+	if !errors.Is(err, repository.ErrRecordNotFound) {
+		t.Fatalf("Delete of a deleted subscription: got %v, want %v", err, repository.ErrRecordNotFound)
+	}
+
+	assertIDs(t, getAll(t, r, allSubscriptions(), pages("id", 1, 100)), kept.ID)
+}
+
+func testGetAllFilters(t *testing.T, r service.SubscriptionProvider) {
+	user, otherUser := uuid.New(), uuid.New()
+
+	end := month("2025-12")
+	plus := newSubscription("Yandex Plus", 400, user, "2025-07")
+	music := newSubscription("Yandex Music", 300, user, "2025-03")
+	netflix := newSubscription("Netflix Premium", 900, otherUser, "2025-01")
+	netflix.EndDate = &end
+	mustInsert(t, r, plus, music, netflix)
+
+	hasEndDate := true
+
+	tests := []struct {
+		name   string
+		filter func(*models.SubscriptionsFilter)
+		want   []int
+	}{
+		{"none", func(f *models.SubscriptionsFilter) {}, []int{plus.ID, music.ID, netflix.ID}},
+		{"service name", func(f *models.SubscriptionsFilter) { f.ServiceNames = []string{"yandex"} }, []int{plus.ID, music.ID}},
+		{"service name words", func(f *models.SubscriptionsFilter) { f.ServiceNames = []string{"plus yandex"} }, []int{plus.ID}},
+		{"service names", func(f *models.SubscriptionsFilter) { f.ServiceNames = []string{"music", "netflix"} }, []int{music.ID, netflix.ID}},
+		{"user", func(f *models.SubscriptionsFilter) { f.UserIDs = []uuid.UUID{otherUser} }, []int{netflix.ID}},
+		{"price", func(f *models.SubscriptionsFilter) { f.Price = 300 }, []int{music.ID}},
+		{"price range", func(f *models.SubscriptionsFilter) { f.PriceMin, f.PriceMax = 350, 900 }, []int{plus.ID, netflix.ID}},
+		{"start range", func(f *models.SubscriptionsFilter) { f.StartFrom, f.StartTo = month("2025-02"), month("2025-07") }, []int{plus.ID, music.ID}},
+		{"active on", func(f *models.SubscriptionsFilter) { f.ActiveOn = month("2025-05") }, []int{music.ID, netflix.ID}},
+		{"has end date", func(f *models.SubscriptionsFilter) { f.HasEndDate = &hasEndDate }, []int{netflix.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := allSubscriptions()
+			tt.filter(&filter)
+
+			assertIDs(t, getAll(t, r, filter, pages("id", 1, 100)), tt.want...)
+		})
+	}
+}
+
+func testGetAllSort(t *testing.T, r service.SubscriptionProvider) {
+	user := uuid.New()
+
+	first := newSubscription("Netflix", 900, user, "2025-01")
+	second := newSubscription("Yandex Plus", 400, user, "2025-07")
+	third := newSubscription("Netflix", 500, user, "2025-03")
+	fourth := newSubscription("Spotify", 400, user, "2025-02")
+	mustInsert(t, r, first, second, third, fourth)
+
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{"-id", []int{fourth.ID, third.ID, second.ID, first.ID}},
+		{"price", []int{second.ID, fourth.ID, third.ID, first.ID}},
+		{"-price", []int{first.ID, third.ID, second.ID, fourth.ID}},
+		{"start_date", []int{first.ID, fourth.ID, third.ID, second.ID}},
+		{"service_name,-price", []int{first.ID, third.ID, fourth.ID, second.ID}},
+		{"-service_name,price", []int{second.ID, fourth.ID, third.ID, first.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			assertIDs(t, getAll(t, r, allSubscriptions(), pages(tt.sort, 1, 100)), tt.want...)
+		})
+	}
+}
+
+func testGetAllPages(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+	ids := insertPriced(t, r, 100, 200, 300, 400, 500)
+
+	subscriptions, metadata, err := r.GetAll(ctx, allSubscriptions(), "", pages("price", 2, 2))
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, subscriptions, ids[2], ids[3])
+
+	if want := models.CalculateMetadata(5, 2, 2); metadata != want {
+		t.Fatalf("GetAll metadata: got %+v, want %+v", metadata, want)
+	}
+
+	subscriptions, _, err = r.GetAll(ctx, allSubscriptions(), "", pages("price", 4, 2))
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, subscriptions)
+}
+
+func testGetAllCursor(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+	ids := insertPriced(t, r, 100, 200, 300, 400, 500)
+
+	filters := pages("-price", 1, 2)
+
+	first, _, err := r.GetAll(ctx, allSubscriptions(), "", filters)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	assertIDs(t, first, ids[4], ids[3])
+
+	tests := []struct {
+		name     string
+		cursor   func(*models.Filters)
+		want     []int
+		next     bool
+		previous bool
+	}{
+		{"after first page", func(f *models.Filters) { f.After = cursor(*f, first[1]) }, []int{ids[2], ids[1]}, true, true},
+		{"after second page", func(f *models.Filters) { f.After = cursor(*f, subscriptionByID(t, r, ids[1])) }, []int{ids[0]}, false, true},
+		{"before second page", func(f *models.Filters) { f.Before = cursor(*f, subscriptionByID(t, r, ids[2])) }, []int{ids[4], ids[3]}, true, false},
+		{"after last", func(f *models.Filters) { f.After = cursor(*f, subscriptionByID(t, r, ids[0])) }, nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := filters
+			tt.cursor(&page)
+
+			subscriptions, metadata, err := r.GetAll(ctx, allSubscriptions(), "", page)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			assertIDs(t, subscriptions, tt.want...)
+
+			if metadata.HasNext != tt.next || metadata.HasPrev != tt.previous {
+				t.Fatalf("GetAll metadata: got next %t and previous %t, want %t and %t", metadata.HasNext, metadata.HasPrev, tt.next, tt.previous)
+			}
+		})
+	}
+}
+
+func testGetSubscriptionsSum(t *testing.T, r service.SubscriptionProvider) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	end := month("2025-03")
+	monthly := newSubscription("Yandex Plus", 100, user, "2025-01")
+	monthly.EndDate = &end
+	yearly := newSubscription("Netflix", 1200, user, "2025-02")
+	yearly.BillingPeriod = models.BillingPeriodYearly
+	deleted := newSubscription("Spotify", 300, user, "2025-01")
+	otherUser := newSubscription("Yandex Plus", 700, uuid.New(), "2025-01")
+	mustInsert(t, r, monthly, yearly, deleted, otherUser)
+
+	err := r.Delete(ctx, deleted.ID, 0)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	sum, err := r.GetSubscriptionsSum(ctx, user, "", month("2025-01"), month("2025-04"), models.DefaultCurrency)
+	if err != nil {
+		t.Fatalf("GetSubscriptionsSum: %v", err)
+	}
+	assertSum(t, sum, 100, 200, 200, 100)
+
+	sum, err = r.GetSubscriptionsSum(ctx, uuid.Nil, "Yandex Plus", month("2025-01"), month("2025-02"), models.DefaultCurrency)
+	if err != nil {
+		t.Fatalf("GetSubscriptionsSum: %v", err)
+	}
+	assertSum(t, sum, 800, 800)
+}
+
+// newSubscription returns a monthly subscription in the default currency starting in the month formatted as 2006-01.
+func newSubscription(serviceName string, price int, userID uuid.UUID, start string) *models.Subscription {
+	return &models.Subscription{
+		ServiceName:   serviceName,
+		Price:         &price,
+		Currency:      models.DefaultCurrency,
+		BillingPeriod: models.BillingPeriodMonthly,
+		UserID:        userID,
+		StartDate:     month(start),
+	}
+}
+
+// month parses a month formatted as 2006-01.
+func month(s string) models.CustomDate {
+	t, err := time.Parse("2006-01", s)
+	if err != nil {
+		panic(err)
+	}
+
+	return models.CustomDate(t)
+}
+
+// allSubscriptions returns a filter matching every subscription.
+func allSubscriptions() models.SubscriptionsFilter {
+	return models.SubscriptionsFilter{Price: -1, PriceMin: -1, PriceMax: -1}
+}
+
+func pages(sort string, page, pageSize int) models.Filters {
+	return models.Filters{Page: page, PageSize: pageSize, Sort: sort, SortSafelist: sortSafelist}
+}
+
+func cursor(filters models.Filters, subscription *models.Subscription) *models.Cursor {
+	c := models.NewSubscriptionCursor(filters, subscription)
+	return &c
+}
+
+func mustInsert(t *testing.T, r service.SubscriptionProvider, subscriptions ...*models.Subscription) {
+	t.Helper()
+
+	for _, subscription := range subscriptions {
+		err := r.Insert(context.Background(), subscription)
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+}
+
+// insertPriced inserts a subscription for every price and returns their ids.
+func insertPriced(t *testing.T, r service.SubscriptionProvider, prices ...int) []int {
+	t.Helper()
+
+	var ids []int
+	for _, price := range prices {
+		subscription := newSubscription("Yandex Plus", price, uuid.New(), "2025-01")
+		mustInsert(t, r, subscription)
+		ids = append(ids, subscription.ID)
+	}
+
+	return ids
+}
+
+func subscriptionByID(t *testing.T, r service.SubscriptionProvider, id int) *models.Subscription {
+	t.Helper()
+
+	subscription, err := r.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	return subscription
+}
+
+func getAll(t *testing.T, r service.SubscriptionProvider, filter models.SubscriptionsFilter, filters models.Filters) []*models.Subscription {
+	t.Helper()
+
+	subscriptions, _, err := r.GetAll(context.Background(), filter, "", filters)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+
+	return subscriptions
+}
+
+func assertIDs(t *testing.T, subscriptions []*models.Subscription, want ...int) {
+	t.Helper()
+
+	got := []int{}
+	for _, subscription := range subscriptions {
+		got = append(got, subscription.ID)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("got subscriptions %v, want %v", got, want)
+	}
+}
+
+func assertSubscription(t *testing.T, got, want *models.Subscription) {
+	t.Helper()
+
+	equal := got.ID == want.ID &&
+		got.ServiceName == want.ServiceName &&
+		*got.Price == *want.Price &&
+		got.Currency == want.Currency &&
+		got.BillingPeriod == want.BillingPeriod &&
+		got.UserID == want.UserID &&
+		got.StartDate.Time().Equal(want.StartDate.Time()) &&
+		(got.EndDate == nil) == (want.EndDate == nil) &&
+		(got.EndDate == nil || got.EndDate.Time().Equal(want.EndDate.Time())) &&
+		got.Version == want.Version
+
+	if !equal {
+		t.Fatalf("got subscription %+v, want %+v", got, want)
+	}
+}
+
+func assertSum(t *testing.T, sum *models.SubscriptionsSum, months ...int) {
+	t.Helper()
+
+	got := []int{}
+	for _, month := range sum.Months {
+		got = append(got, month.Sum)
+	}
+
+	total := 0
+	for _, amount := range months {
+		total += amount
+	}
+
+	if !slices.Equal(got, months) || sum.Total != total {
+		t.Fatalf("got monthly sums %v and total %d, want %v and %d", got, sum.Total, months, total)
+	}
+}
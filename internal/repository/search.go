@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Lexemes splits s into lower-cased words the way the 'simple' text search configuration
+// of Postgres does. Storages without full-text search use it to match service names.
+func Lexemes(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ContainsLexemes reports whether words contain every lexeme of a non-empty query.
+func ContainsLexemes(words []string, query []string) bool {
+	for _, lexeme := range query {
+		if !slices.Contains(words, lexeme) {
+			return false
+		}
+	}
+
+	return len(query) > 0
+}
@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewAPIKeyRepository(db *sql.DB, timeouts config.QueryTimeouts) *APIKeyRepository {
+	return &APIKeyRepository{db: db, timeouts: timeouts}
+}
+
+func (r *APIKeyRepository) Insert(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	// scopes are stored as a JSON array.
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt any
+	if key.ExpiresAt != nil {
+		expiresAt = key.ExpiresAt.UTC().Format(timestampLayout)
+	}
+
+	err = r.db.QueryRowContext(ctx, query, key.Name, key.Prefix, key.Hash, string(scopes), expiresAt).Scan(&key.ID, &key.CreatedAt)
+
+	return repository.ContextError(ctx, err)
+}
+
+// GetAll returns all keys including revoked and expired ones.
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		ORDER BY id;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+
+	for rows.Next() {
+		var key models.APIKey
+		var scopes []byte
+
+		err = rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&scopes,
+			&key.CreatedAt,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+		)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		err = json.Unmarshal(scopes, &key.Scopes)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	return keys, nil
+}
+
+// GetByHash returns the key with the hash unless it has been revoked.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash []byte) (*models.APIKey, error) {
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var key models.APIKey
+	var scopes []byte
+
+	err := r.db.QueryRowContext(ctx, query, hash).Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&scopes,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrRecordNotFound
+		default:
+			return nil, repository.ContextError(ctx, err)
+		}
+	}
+
+	err = json.Unmarshal(scopes, &key.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Revoke marks the key as revoked, it cannot be used afterwards.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return repository.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Touch records when the key was last used.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, id, usedAt.UTC().Format(timestampLayout))
+
+	return repository.ContextError(ctx, err)
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+)
+
+type ExchangeRateRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewExchangeRateRepository(db *sql.DB, timeouts config.QueryTimeouts) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db, timeouts: timeouts}
+}
+
+// InsertMany stores rates in a single transaction, replacing already known rates
+// for the same currency pair and effective date.
+func (r *ExchangeRateRepository) InsertMany(ctx context.Context, rates []*models.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rates(base_currency, quote_currency, rate, effective_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, effective_date) DO UPDATE SET rate = excluded.rate;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		_, err := stmt.ExecContext(ctx, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, formatDate(rate.EffectiveDate))
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
@@ -0,0 +1,80 @@
+// Package sqlite implements storage in a single SQLite database file.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/repository"
+	"fmt"
+	"modernc.org/sqlite"
+	"time"
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	timestampLayout = "2006-01-02 15:04:05"
+)
+
+func init() {
+	// matches_search(text, query) emulates the Postgres full-text search of service names.
+	sqlite.MustRegisterDeterministicScalarFunction("matches_search", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		text, _ := args[0].(string)
+		query, _ := args[1].(string)
+
+		if repository.ContainsLexemes(repository.Lexemes(text), repository.Lexemes(query)) {
+			return int64(1), nil
+		}
+
+		return int64(0), nil
+	})
+}
+
+// NewSQLiteDB opens the database file. Write transactions take the database lock immediately
+// so that concurrent writers wait for each other instead of failing on lock upgrade.
+func NewSQLiteDB(cfg config.SQLiteConfig) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", cfg.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// formatDate formats a date the way it is stored in DATE columns.
+func formatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+// parseDate parses a date computed by a query, computed columns are returned as text.
+func parseDate(s string) (time.Time, error) {
+	return time.Parse(dateLayout, s)
+}
+
+// beginWrite starts a transaction changing subscriptions. The actor from ctx is stored in the
+// history_actor table, the history triggers record it as the author of the changes.
+// Write transactions hold the database lock, so concurrent transactions cannot overwrite it.
+func beginWrite(ctx context.Context, db *sql.DB) (*sql.Tx, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE history_actor SET actor = $1 WHERE id = 1;`, repository.Actor(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return tx, nil
+}
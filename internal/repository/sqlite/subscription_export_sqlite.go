@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"fmt"
+)
+
+// Export calls fn for every subscription matching filter in the sort order of filters, without pagination.
+// Rows are read from the database one at a time, so the result does not have to fit in memory.
+// If currency is not empty every subscription price is also converted to it like in GetAll. A missing exchange rate
+// is reported with repository.ErrExchangeRateNotFound before fn is called.
+func (r *SubscriptionRepository) Export(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters, fn func(*models.Subscription) error) error {
+	conditions := subscriptionConditions(filter)
+
+	where := conditions.SQL()
+	rate := exchangeRateSQL("currency", conditions.Arg(currency), billingMonthSQL)
+
+	query := fmt.Sprintf(`
+		SELECT id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version,
+			CAST(ROUND(price * %s) AS INTEGER)
+		FROM subscriptions
+		WHERE %s
+		ORDER BY %s`, rate, where, repository.OrderBySQL(filters.SortKeys(), false))
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Export)
+	defer cancel()
+
+	// Rates are checked before the first row, so that the export is not cut off halfway.
+	if currency != "" {
+		var missing bool
+
+		err := r.db.QueryRowContext(ctx, fmt.Sprintf(`
+			SELECT EXISTS (SELECT 1 FROM subscriptions WHERE %s AND %s IS NULL)`, where, rate), conditions.Args()...).Scan(&missing)
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+
+		if missing {
+			return repository.ErrExchangeRateNotFound
+		}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, conditions.Args()...)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var subscription models.Subscription
+		var convertedPrice *int
+		err := rows.Scan(
+			&subscription.ID,
+			&subscription.ServiceName,
+			&subscription.Price,
+			&subscription.Currency,
+			&subscription.BillingPeriod,
+			&subscription.UserID,
+			&subscription.StartDate,
+			&subscription.EndDate,
+			&subscription.CreatedAt,
+			&subscription.Version,
+			&convertedPrice,
+		)
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+
+		if currency != "" {
+			if convertedPrice == nil {
+				return repository.ErrExchangeRateNotFound
+			}
+			subscription.Converted = &models.ConvertedPrice{Price: *convertedPrice, Currency: currency}
+		}
+
+		err = fn(&subscription)
+		if err != nil {
+			return err
+		}
+	}
+
+	return repository.ContextError(ctx, rows.Err())
+}
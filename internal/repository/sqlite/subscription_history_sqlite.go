@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"encoding/json"
+	"errors"
+)
+
+// GetHistory returns all recorded changes of the subscription ordered by version.
+// History is kept for deleted and purged subscriptions as well.
+func (r *SubscriptionRepository) GetHistory(ctx context.Context, subscriptionID int) ([]*models.SubscriptionHistory, error) {
+	query := `
+		SELECT id, subscription_id, version, operation, changed_by, changed_at, changed_fields, old_values, new_values
+		FROM subscription_history
+		WHERE subscription_id = $1
+		ORDER BY version ASC, id ASC;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	history := []*models.SubscriptionHistory{}
+
+	for rows.Next() {
+		var record models.SubscriptionHistory
+		var changedFields, oldValues, newValues []byte
+		err := rows.Scan(
+			&record.ID,
+			&record.SubscriptionID,
+			&record.Version,
+			&record.Operation,
+			&record.ChangedBy,
+			&record.ChangedAt,
+			&changedFields,
+			&oldValues,
+			&newValues,
+		)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		// changed_fields is stored as a JSON array.
+		err = json.Unmarshal(changedFields, &record.ChangedFields)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		record.OldValues, err = unmarshalSubscription(oldValues)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+		record.NewValues, err = unmarshalSubscription(newValues)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		history = append(history, &record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	if len(history) == 0 {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	return history, nil
+}
+
+// GetVersion returns the subscription as it was right after the change with the given version.
+func (r *SubscriptionRepository) GetVersion(ctx context.Context, subscriptionID int, version int) (*models.Subscription, error) {
+	query := `
+		SELECT new_values
+		FROM subscription_history
+		WHERE subscription_id = $1 AND version = $2 AND new_values IS NOT NULL
+		ORDER BY id DESC
+		LIMIT 1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	var values []byte
+	err := r.db.QueryRowContext(ctx, query, subscriptionID, version).Scan(&values)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrRecordNotFound
+		default:
+			return nil, repository.ContextError(ctx, err)
+		}
+	}
+
+	return unmarshalSubscription(values)
+}
+
+func unmarshalSubscription(data []byte) (*models.Subscription, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var subscription models.Subscription
+	err := json.Unmarshal(data, &subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+)
+
+// InsertPrice records a price change of the subscription. A price already recorded for the same
+// month is replaced. Unless the new price starts in the future, the subscription price is set to
+// the latest price effective today and its version is incremented.
+func (r *SubscriptionRepository) InsertPrice(ctx context.Context, price *models.SubscriptionPrice) error {
+	query := `
+		UPDATE subscriptions
+		SET price = COALESCE((
+				SELECT p.price FROM subscription_prices p
+				WHERE p.subscription_id = $1 AND p.effective_date <= CURRENT_DATE
+				ORDER BY p.effective_date DESC
+				LIMIT 1), price),
+			version = version + 1
+		WHERE id = $1 AND $2 <= CURRENT_DATE;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM subscriptions WHERE id = $1 AND deleted_at IS NULL;`, price.SubscriptionID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrRecordNotFound
+		default:
+			return repository.ContextError(ctx, err)
+		}
+	}
+
+	err = insertPrice(ctx, tx, price)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	_, err = tx.ExecContext(ctx, query, price.SubscriptionID, formatDate(price.EffectiveDate.Time()))
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+func (r *SubscriptionRepository) GetPrices(ctx context.Context, subscriptionID int) ([]*models.SubscriptionPrice, error) {
+	query := `
+		SELECT id, subscription_id, price, effective_date, created_at
+		FROM subscription_prices
+		WHERE subscription_id = $1
+		ORDER BY effective_date ASC;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, subscriptionID)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	prices := []*models.SubscriptionPrice{}
+
+	for rows.Next() {
+		var price models.SubscriptionPrice
+		err := rows.Scan(
+			&price.ID,
+			&price.SubscriptionID,
+			&price.Price,
+			&price.EffectiveDate,
+			&price.CreatedAt,
+		)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		prices = append(prices, &price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	return prices, nil
+}
+
+// insertPrice upserts the price effective from price.EffectiveDate inside tx.
+func insertPrice(ctx context.Context, tx *sql.Tx, price *models.SubscriptionPrice) error {
+	query := `
+		INSERT INTO subscription_prices(subscription_id, price, effective_date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subscription_id, effective_date) DO UPDATE SET price = excluded.price
+		RETURNING id, created_at;`
+
+	args := []any{price.SubscriptionID, price.Price, formatDate(price.EffectiveDate.Time())}
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(&price.ID, &price.CreatedAt)
+}
@@ -0,0 +1,473 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+type SubscriptionRepository struct {
+	db       *sql.DB
+	timeouts config.QueryTimeouts
+}
+
+func NewSubscriptionRepository(db *sql.DB, timeouts config.QueryTimeouts) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db, timeouts: timeouts}
+}
+
+// Insert stores the subscription together with its initial price effective from start_date.
+func (r *SubscriptionRepository) Insert(ctx context.Context, subscription *models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = insertSubscription(ctx, tx, subscription)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// InsertMany stores the subscriptions together with their initial prices in a single transaction.
+func (r *SubscriptionRepository) InsertMany(ctx context.Context, subscriptions []*models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	for _, subscription := range subscriptions {
+		err = insertSubscription(ctx, tx, subscription)
+		if err != nil {
+			return repository.ContextError(ctx, err)
+		}
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+func (r *SubscriptionRepository) Get(ctx context.Context, id int) (*models.Subscription, error) {
+	if id < 1 {
+		return nil, repository.ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version
+		FROM subscriptions
+		WHERE id = $1 AND deleted_at IS NULL;`
+
+	var subscription models.Subscription
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&subscription.ID,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&subscription.Currency,
+		&subscription.BillingPeriod,
+		&subscription.UserID,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.CreatedAt,
+		&subscription.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrRecordNotFound
+		default:
+			return nil, repository.ContextError(ctx, err)
+		}
+	}
+
+	return &subscription, nil
+}
+
+// Update saves the subscription if its version has not changed since it was read.
+// A changed price is recorded in the price history as effective from the current month,
+// so costs of the previous months are not affected.
+func (r *SubscriptionRepository) Update(ctx context.Context, subscription *models.Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	// The transaction holds the write lock from the start, so the previous price
+	// cannot change between reading it and updating the row.
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = updateSubscription(ctx, tx, subscription)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// Delete moves the subscription to the trash. It can be restored until it is purged. If version is not zero
+// the subscription is deleted only if it still has this version, otherwise ErrEditConflict is returned.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int, version int) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = deleteSubscription(ctx, tx, id, version)
+	if err != nil {
+		return repository.ContextError(ctx, err)
+	}
+
+	return repository.ContextError(ctx, tx.Commit())
+}
+
+// insertSubscription stores the subscription together with its initial price inside tx.
+func insertSubscription(ctx context.Context, tx *sql.Tx, subscription *models.Subscription) error {
+	query := `
+		INSERT INTO subscriptions(service_name, price, currency, billing_period, user_id, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, version;`
+
+	args := []any{subscription.ServiceName, subscription.Price, subscription.Currency, subscription.BillingPeriod, subscription.UserID, formatDate(subscription.StartDate.Time())}
+	if subscription.EndDate != nil {
+		args = append(args, formatDate(subscription.EndDate.Time()))
+	} else {
+		args = append(args, nil)
+	}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.Version)
+	if err != nil {
+		return err
+	}
+
+	return insertPrice(ctx, tx, &models.SubscriptionPrice{
+		SubscriptionID: subscription.ID,
+		Price:          subscription.Price,
+		EffectiveDate:  subscription.StartDate,
+	})
+}
+
+// updateSubscription saves the subscription inside tx if its version has not changed since it was read.
+func updateSubscription(ctx context.Context, tx *sql.Tx, subscription *models.Subscription) error {
+	query := `
+		UPDATE subscriptions
+		SET service_name = $1, price = $2, currency = $3, billing_period = $4, user_id = $5, start_date = $6, end_date = $7,
+			version = version + 1
+		WHERE id = $8 AND version = $9 AND deleted_at IS NULL
+		RETURNING version;`
+
+	args := []any{
+		subscription.ServiceName,
+		subscription.Price,
+		subscription.Currency,
+		subscription.BillingPeriod,
+		subscription.UserID,
+		formatDate(subscription.StartDate.Time()),
+		nil,
+		subscription.ID,
+		subscription.Version}
+
+	if subscription.EndDate != nil {
+		args[6] = formatDate(subscription.EndDate.Time())
+	}
+
+	var previousPrice int
+	err := tx.QueryRowContext(ctx, `SELECT price FROM subscriptions WHERE id = $1;`, subscription.ID).Scan(&previousPrice)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&subscription.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return repository.ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	if previousPrice != *subscription.Price {
+		now := time.Now().UTC()
+		effectiveDate := models.CustomDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		if effectiveDate.Time().Before(subscription.StartDate.Time()) {
+			effectiveDate = subscription.StartDate
+		}
+
+		return insertPrice(ctx, tx, &models.SubscriptionPrice{
+			SubscriptionID: subscription.ID,
+			Price:          subscription.Price,
+			EffectiveDate:  effectiveDate,
+		})
+	}
+
+	return nil
+}
+
+// deleteSubscription moves the subscription to the trash inside tx.
+func deleteSubscription(ctx context.Context, tx *sql.Tx, id int, version int) error {
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2);`
+
+	result, err := tx.ExecContext(ctx, query, id, version)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case rowsAffected == 0 && version != 0:
+		return repository.ErrEditConflict
+	case rowsAffected == 0:
+		return repository.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetAll returns a page of subscriptions matching filter. If currency is not empty every subscription price is also
+// converted to it using the exchange rate effective in its billing month, like the same month of a sum.
+// A subscription without such a rate fails the request with repository.ErrExchangeRateNotFound.
+func (r *SubscriptionRepository) GetAll(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	conditions := subscriptionConditions(filter)
+
+	// Keyset pages are read one row past the page size to find out whether more rows follow.
+	// They are not counted, since a window count has to scan every matching row.
+	count, limit, offset := "COUNT(*) OVER ()", filters.Limit(), filters.Offset()
+	cursor, backward := filters.Cursor()
+	if cursor != nil {
+		count, limit, offset = "0", filters.Limit()+1, 0
+
+		keyset, args := repository.KeysetSQL(filters.SortKeys(), backward, cursor.Values)
+		conditions.Add(keyset, args...)
+	}
+
+	where := conditions.SQL()
+	rate := exchangeRateSQL("currency", conditions.Arg(currency), billingMonthSQL)
+
+	query := fmt.Sprintf(`
+		SELECT %s, id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version,
+			CAST(ROUND(price * %s) AS INTEGER)
+		FROM subscriptions
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, count, rate, where, repository.OrderBySQL(filters.SortKeys(), backward), conditions.Arg(limit), conditions.Arg(offset))
+
+	args := conditions.Args()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		var subscription models.Subscription
+		var convertedPrice *int
+		err := rows.Scan(
+			&totalRecords,
+			&subscription.ID,
+			&subscription.ServiceName,
+			&subscription.Price,
+			&subscription.Currency,
+			&subscription.BillingPeriod,
+			&subscription.UserID,
+			&subscription.StartDate,
+			&subscription.EndDate,
+			&subscription.CreatedAt,
+			&subscription.Version,
+			&convertedPrice,
+		)
+		if err != nil {
+			return nil, models.Metadata{}, repository.ContextError(ctx, err)
+		}
+
+		if currency != "" {
+			if convertedPrice == nil {
+				return nil, models.Metadata{}, repository.ErrExchangeRateNotFound
+			}
+			subscription.Converted = &models.ConvertedPrice{Price: *convertedPrice, Currency: currency}
+		}
+
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
+	}
+
+	if cursor != nil {
+		subscriptions, metadata := repository.KeysetPage(subscriptions, filters)
+		return subscriptions, metadata, nil
+	}
+
+	metadata := models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return subscriptions, metadata, nil
+}
+
+// subscriptionConditions returns the conditions selecting subscriptions matching filter.
+func subscriptionConditions(filter models.SubscriptionsFilter) *repository.Conditions {
+	return repository.SubscriptionConditions(filter, "matches_search(service_name, ?)", func(t time.Time) any { return formatDate(t) })
+}
+
+// GetSubscriptionsSum charges every subscription for each month it is active within [beginDate, endDate]
+// the same way the Postgres storage does. Months are generated by a recursive query, which yields
+// no rows when the period ends before the first subscription starts.
+func (r *SubscriptionRepository) GetSubscriptionsSum(ctx context.Context, userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate, currency string) (*models.SubscriptionsSum, error) {
+	query := fmt.Sprintf(`
+		WITH RECURSIVE filtered AS (
+			SELECT id, price, currency, billing_period, start_date, end_date
+			FROM subscriptions
+			WHERE deleted_at IS NULL
+				AND (service_name = $3 OR $3 = '')
+				AND (user_id = $4 OR $4 = '00000000-0000-0000-0000-000000000000')
+		), bounds AS (
+			SELECT max(date($1, 'start of month'), date(MIN(start_date), 'start of month')) AS first_month,
+				date($2, 'start of month') AS last_month
+			FROM filtered
+			HAVING COUNT(*) > 0
+		), months(month, last_month) AS (
+			SELECT first_month, last_month FROM bounds WHERE first_month <= last_month
+			UNION ALL
+			SELECT date(month, '+1 month'), last_month FROM months WHERE month < last_month
+		), charges AS (
+			SELECT m.month, f.currency, COALESCE(%s, f.price) * %s AS monthly_price
+			FROM months m
+			LEFT JOIN filtered f ON f.start_date <= m.month AND (f.end_date IS NULL OR f.end_date >= m.month)
+		), converted AS (
+			SELECT c.month, c.monthly_price, c.monthly_price * %s AS amount
+			FROM charges c
+		)
+		SELECT month, CAST(COALESCE(ROUND(SUM(amount)), 0) AS INTEGER),
+			COUNT(*) FILTER (WHERE monthly_price IS NOT NULL AND amount IS NULL)
+		FROM converted
+		GROUP BY month
+		ORDER BY month;`,
+		effectivePriceSQL("f.id", "m.month"), monthlyFactorSQL("f.billing_period"), exchangeRateSQL("c.currency", "$5", "c.month"))
+
+	args := []any{formatDate(beginDate.Time()), formatDate(endDate.Time()), serviceName, userID, currency}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Report)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	sum := &models.SubscriptionsSum{Currency: currency, Months: []models.MonthlySum{}}
+
+	for rows.Next() {
+		var month models.MonthlySum
+		var monthDate string
+		var unconverted int
+		err := rows.Scan(&monthDate, &month.Sum, &unconverted)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+
+		if unconverted > 0 {
+			return nil, repository.ErrExchangeRateNotFound
+		}
+
+		t, err := parseDate(monthDate)
+		if err != nil {
+			return nil, repository.ContextError(ctx, err)
+		}
+		month.Month = models.CustomDate(t)
+
+		sum.Total += month.Sum
+		sum.Months = append(sum.Months, month)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	return sum, nil
+}
+
+// monthlyFactorSQL returns an SQL expression that converts a price billed with the period stored
+// in column to the equivalent monthly cost.
+func monthlyFactorSQL(column string) string {
+	return fmt.Sprintf(`(CASE %s
+		WHEN 'weekly' THEN 52.0 / 12
+		WHEN 'quarterly' THEN 1.0 / 3
+		WHEN 'yearly' THEN 1.0 / 12
+		ELSE 1 END)`, column)
+}
+
+// effectivePriceSQL returns an SQL expression that evaluates to the price of subscription effective
+// on date according to the price history. The expression is NULL when the history has no such price.
+func effectivePriceSQL(subscriptionID, date string) string {
+	return fmt.Sprintf(`(
+		SELECT p.price
+		FROM subscription_prices p
+		WHERE p.subscription_id = %s AND p.effective_date <= %s
+		ORDER BY p.effective_date DESC
+		LIMIT 1)`, subscriptionID, date)
+}
+
+// billingMonthSQL an SQL expression that evaluates to the month the current price of a subscription is charged
+// for, see models.Subscription.BillingMonth.
+const billingMonthSQL = `(CASE
+		WHEN start_date > date('now', 'start of month') THEN start_date
+		WHEN end_date < date('now', 'start of month') THEN end_date
+		ELSE date('now', 'start of month') END)`
+
+// exchangeRateSQL returns an SQL expression that evaluates to the rate converting currencyColumn
+// to target currency effective on date. Rates stored in the opposite direction are inverted.
+// The expression is NULL when no suitable rate exists.
+func exchangeRateSQL(currencyColumn, target, date string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s = %[2]s THEN 1 ELSE (
+		SELECT r.rate
+		FROM (
+			SELECT rate, effective_date FROM exchange_rates
+			WHERE base_currency = %[1]s AND quote_currency = %[2]s AND effective_date <= %[3]s
+			UNION ALL
+			SELECT 1 / rate, effective_date FROM exchange_rates
+			WHERE base_currency = %[2]s AND quote_currency = %[1]s AND effective_date <= %[3]s
+		) r
+		ORDER BY r.effective_date DESC
+		LIMIT 1) END)`, currencyColumn, target, date)
+}
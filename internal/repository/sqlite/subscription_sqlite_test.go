@@ -0,0 +1,49 @@
+package sqlite_test
+
+import (
+	"eff-subscriptions/internal/config"
+	"eff-subscriptions/internal/migrator"
+	"eff-subscriptions/internal/repository/repotest"
+	"eff-subscriptions/internal/repository/sqlite"
+	"eff-subscriptions/internal/service"
+	"eff-subscriptions/migrations"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionRepository(t *testing.T) {
+	repotest.SubscriptionRepository(t, func(t *testing.T) service.SubscriptionProvider {
+		db, err := sqlite.NewSQLiteDB(config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "subscriptions.db")})
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { _ = db.Close() })
+
+		schema, err := fs.Sub(migrations.SQLite, "sqlite")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := migrator.New(db, migrator.SQLite, schema)
+		if err != nil {
+			t.Fatalf("create migrator: %v", err)
+		}
+
+		_, err = m.Up()
+		if err != nil {
+			t.Fatalf("apply migrations: %v", err)
+		}
+
+		return sqlite.NewSubscriptionRepository(db, timeouts)
+	})
+}
+
+var timeouts = config.QueryTimeouts{
+	Read:        5 * time.Second,
+	Write:       5 * time.Second,
+	Report:      5 * time.Second,
+	Export:      5 * time.Second,
+	Maintenance: 5 * time.Second,
+}
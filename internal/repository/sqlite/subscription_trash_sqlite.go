@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetTrash returns a page of deleted subscriptions that have not been purged yet.
+func (r *SubscriptionRepository) GetTrash(ctx context.Context, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER (), id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at,
+			deleted_at, version
+		FROM subscriptions
+		WHERE deleted_at IS NOT NULL
+		ORDER BY %s
+		LIMIT $1 OFFSET $2`, repository.OrderBySQL(filters.SortKeys(), false))
+
+	args := []any{filters.Limit(), filters.Offset()}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	var subscriptions []*models.Subscription
+
+	for rows.Next() {
+		var subscription models.Subscription
+		err := rows.Scan(
+			&totalRecords,
+			&subscription.ID,
+			&subscription.ServiceName,
+			&subscription.Price,
+			&subscription.Currency,
+			&subscription.BillingPeriod,
+			&subscription.UserID,
+			&subscription.StartDate,
+			&subscription.EndDate,
+			&subscription.CreatedAt,
+			&subscription.DeletedAt,
+			&subscription.Version,
+		)
+		if err != nil {
+			return nil, models.Metadata{}, repository.ContextError(ctx, err)
+		}
+
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, models.Metadata{}, repository.ContextError(ctx, err)
+	}
+
+	metadata := models.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return subscriptions, metadata, nil
+}
+
+// Restore takes the subscription out of the trash.
+func (r *SubscriptionRepository) Restore(ctx context.Context, id int) (*models.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, service_name, price, currency, billing_period, user_id, start_date, end_date, created_at, version;`
+
+	var subscription models.Subscription
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, id).Scan(
+		&subscription.ID,
+		&subscription.ServiceName,
+		&subscription.Price,
+		&subscription.Currency,
+		&subscription.BillingPeriod,
+		&subscription.UserID,
+		&subscription.StartDate,
+		&subscription.EndDate,
+		&subscription.CreatedAt,
+		&subscription.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, repository.ErrRecordNotFound
+		default:
+			return nil, repository.ContextError(ctx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, repository.ContextError(ctx, err)
+	}
+
+	return &subscription, nil
+}
+
+// Purge permanently deletes subscriptions moved to the trash before the given time
+// and returns the number of deleted subscriptions. Their price history is deleted by cascade.
+func (r *SubscriptionRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `DELETE FROM subscriptions WHERE deleted_at < $1;`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeouts.Maintenance)
+	defer cancel()
+
+	tx, err := beginWrite(ctx, r.db)
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, deletedBefore.UTC().Format(timestampLayout))
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, repository.ContextError(ctx, err)
+	}
+
+	return int(rowsAffected), nil
+}
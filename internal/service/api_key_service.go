@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/repository"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrInvalidAPIKey the key is unknown, revoked or expired.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// apiKeyTouchInterval limits how often the last use of a key is written to the storage.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyProvider interface {
+	Insert(ctx context.Context, key *models.APIKey) error
+	GetAll(ctx context.Context) ([]*models.APIKey, error)
+	GetByHash(ctx context.Context, hash []byte) (*models.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+	Touch(ctx context.Context, id int, usedAt time.Time) error
+}
+
+type APIKeyService struct {
+	log            *slog.Logger
+	apiKeyProvider APIKeyProvider
+}
+
+func NewAPIKeyService(log *slog.Logger, apiKeyProvider APIKeyProvider) *APIKeyService {
+	return &APIKeyService{
+		log:            log,
+		apiKeyProvider: apiKeyProvider,
+	}
+}
+
+// Create generates a new secret for the key, stores its hash and returns the secret. The secret cannot be
+// recovered afterwards.
+func (s *APIKeyService) Create(ctx context.Context, key *models.APIKey) (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	token := models.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key.Prefix = token[:len(models.APIKeyPrefix)+6]
+	key.Hash = hashAPIKey(token)
+
+	err = s.apiKeyProvider.Insert(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *APIKeyService) GetAll(ctx context.Context) ([]*models.APIKey, error) {
+	return s.apiKeyProvider.GetAll(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int) error {
+	return s.apiKeyProvider.Revoke(ctx, id)
+}
+
+// Authenticate returns the active key matching the token and records its use.
+func (s *APIKeyService) Authenticate(ctx context.Context, token string) (*models.APIKey, error) {
+	key, err := s.apiKeyProvider.GetByHash(ctx, hashAPIKey(token))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRecordNotFound):
+			return nil, ErrInvalidAPIKey
+		default:
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		err = s.apiKeyProvider.Touch(ctx, key.ID, now)
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to record api key use", "id", key.ID, "error", err.Error())
+		}
+	}
+
+	return key, nil
+}
+
+func hashAPIKey(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
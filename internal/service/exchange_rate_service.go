@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/validator"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const exchangeRateDateLayout = "2006-01-02"
+
+type ExchangeRateProvider interface {
+	InsertMany(ctx context.Context, rates []*models.ExchangeRate) error
+}
+
+type ExchangeRateService struct {
+	log                  *slog.Logger
+	exchangeRateProvider ExchangeRateProvider
+}
+
+func NewExchangeRateService(log *slog.Logger, exchangeRateProvider ExchangeRateProvider) *ExchangeRateService {
+	return &ExchangeRateService{
+		log:                  log,
+		exchangeRateProvider: exchangeRateProvider,
+	}
+}
+
+// exchangeRateRecord exchange rate as stored in an import file
+type exchangeRateRecord struct {
+	BaseCurrency  string  `json:"base_currency"`
+	QuoteCurrency string  `json:"quote_currency"`
+	Rate          float64 `json:"rate"`
+	EffectiveDate string  `json:"effective_date"`
+}
+
+// ImportFile loads exchange rates from a local .csv or .json file and stores them.
+// CSV files must have a header with base_currency, quote_currency, rate and effective_date columns,
+// JSON files must contain an array of objects with the same keys. Dates use the YYYY-MM-DD format.
+// It returns the number of imported rates.
+func (s *ExchangeRateService) ImportFile(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var records []exchangeRateRecord
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readExchangeRatesCSV(f)
+	case ".json":
+		err = json.NewDecoder(f).Decode(&records)
+	default:
+		return 0, fmt.Errorf("unsupported file extension %q, expected .csv or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	rates := make([]*models.ExchangeRate, 0, len(records))
+
+	for i, record := range records {
+		rate := &models.ExchangeRate{
+			BaseCurrency:  strings.ToUpper(strings.TrimSpace(record.BaseCurrency)),
+			QuoteCurrency: strings.ToUpper(strings.TrimSpace(record.QuoteCurrency)),
+			Rate:          record.Rate,
+		}
+
+		v := validator.New()
+
+		rate.EffectiveDate, err = time.Parse(exchangeRateDateLayout, strings.TrimSpace(record.EffectiveDate))
+		if err != nil {
+			v.AddError("effective_date", "must be a valid date in YYYY-MM-DD format")
+		}
+
+		if models.ValidateExchangeRate(v, rate); !v.Valid() {
+			return 0, fmt.Errorf("record %d: %s", i+1, formatValidationErrors(v.Errors))
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return 0, errors.New("file contains no exchange rates")
+	}
+
+	err = s.exchangeRateProvider.InsertMany(ctx, rates)
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.InfoContext(ctx, "exchange rates imported", "path", path, "count", len(rates))
+
+	return len(rates), nil
+}
+
+func readExchangeRatesCSV(r io.Reader) ([]exchangeRateRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range []string{"base_currency", "quote_currency", "rate", "effective_date"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header must contain %s column", name)
+		}
+	}
+
+	var records []exchangeRateRecord
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[columns["rate"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: rate must be a number", len(records)+1)
+		}
+
+		records = append(records, exchangeRateRecord{
+			BaseCurrency:  row[columns["base_currency"]],
+			QuoteCurrency: row[columns["quote_currency"]],
+			Rate:          rate,
+			EffectiveDate: row[columns["effective_date"]],
+		})
+	}
+
+	return records, nil
+}
+
+func formatValidationErrors(errs map[string]string) string {
+	keys := make([]string, 0, len(errs))
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+" "+errs[key])
+	}
+
+	return strings.Join(messages, ", ")
+}
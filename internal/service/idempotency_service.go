@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyMismatch the key has already been used with a different request.
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key mismatch")
+	// ErrIdempotencyKeyInProgress a request with the key is still being processed.
+	ErrIdempotencyKeyInProgress = errors.New("idempotency key in progress")
+)
+
+type IdempotencyKeyProvider interface {
+	Reserve(ctx context.Context, key *models.IdempotencyKey, staleBefore time.Time) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, key string, status int, response []byte) error
+	Delete(ctx context.Context, key string) error
+	Purge(ctx context.Context, expiredBefore time.Time) (int, error)
+}
+
+type IdempotencyService struct {
+	log                    *slog.Logger
+	idempotencyKeyProvider IdempotencyKeyProvider
+	ttl                    time.Duration
+	lockTimeout            time.Duration
+}
+
+func NewIdempotencyService(log *slog.Logger, idempotencyKeyProvider IdempotencyKeyProvider, ttl time.Duration, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		log:                    log,
+		idempotencyKeyProvider: idempotencyKeyProvider,
+		ttl:                    ttl,
+		lockTimeout:            lockTimeout,
+	}
+}
+
+// Begin reserves the key for a request with the given hash. It returns nil if the request has to be processed
+// and the stored key with the response if the same request has already been completed. Reusing the key for
+// a different request fails with ErrIdempotencyKeyMismatch, retrying a request which is still being processed
+// fails with ErrIdempotencyKeyInProgress. A request processed for longer than the lock timeout is considered
+// lost, for example with a crashed server, and its key is reserved for the retry.
+func (s *IdempotencyService) Begin(ctx context.Context, key string, requestHash string) (*models.IdempotencyKey, error) {
+	now := time.Now().UTC()
+
+	stored, err := s.idempotencyKeyProvider.Reserve(ctx, &models.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}, now.Add(-s.lockTimeout))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+
+	switch {
+	case stored.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyMismatch
+	case stored.Status == 0:
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response of the request reserved by Begin until the key expires.
+func (s *IdempotencyService) Complete(ctx context.Context, key string, status int, response []byte) error {
+	return s.idempotencyKeyProvider.Complete(ctx, key, status, response)
+}
+
+// Release forgets the key of a request which failed, so that it can be retried.
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	return s.idempotencyKeyProvider.Delete(ctx, key)
+}
+
+// PurgeExpired deletes expired keys every interval until ctx is cancelled.
+func (s *IdempotencyService) PurgeExpired(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.idempotencyKeyProvider.Purge(ctx, time.Now())
+		if err != nil {
+			s.log.Error("failed to purge idempotency keys", "error", err.Error())
+		} else if purged > 0 {
+			s.log.Info("idempotency keys purged", "keys", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"log/slog"
+	"time"
+)
+
+type RateLimitProvider interface {
+	Take(ctx context.Context, key string, limit models.RateLimit, now time.Time) (models.RateLimitResult, error)
+	Purge(ctx context.Context, idleBefore time.Time) (int, error)
+}
+
+type RateLimitService struct {
+	log               *slog.Logger
+	rateLimitProvider RateLimitProvider
+	defaultLimit      models.RateLimit
+	routeLimits       map[string]models.RateLimit
+	ipLimit           models.RateLimit
+}
+
+// NewRateLimitService creates a limiter applying defaultLimit to every route without a limit in routeLimits.
+// Routes are named "METHOD /path" after the route patterns, e.g. "GET /v1/subscriptions/:id".
+// ipLimit applies to each IP address before the caller is authenticated.
+func NewRateLimitService(log *slog.Logger, rateLimitProvider RateLimitProvider, defaultLimit models.RateLimit, routeLimits map[string]models.RateLimit, ipLimit models.RateLimit) *RateLimitService {
+	return &RateLimitService{
+		log:               log,
+		rateLimitProvider: rateLimitProvider,
+		defaultLimit:      defaultLimit,
+		routeLimits:       routeLimits,
+		ipLimit:           ipLimit,
+	}
+}
+
+// Allow takes a token from the bucket of the client for the route and returns the limit it was taken against.
+// Routes with a limit of their own have a bucket of their own, other routes share the default bucket of the client.
+func (s *RateLimitService) Allow(ctx context.Context, client string, route string) (models.RateLimit, models.RateLimitResult, error) {
+	limit, key := s.defaultLimit, client
+	if routeLimit, ok := s.routeLimits[route]; ok {
+		limit, key = routeLimit, client+" "+route
+	}
+
+	result, err := s.rateLimitProvider.Take(ctx, key, limit, time.Now())
+
+	return limit, result, err
+}
+
+// AllowIP takes a token from the bucket of the IP address shared by all requests from it,
+// whoever they are made by, and returns the limit it was taken against.
+func (s *RateLimitService) AllowIP(ctx context.Context, ip string) (models.RateLimit, models.RateLimitResult, error) {
+	result, err := s.rateLimitProvider.Take(ctx, "address:"+ip, s.ipLimit, time.Now())
+
+	return s.ipLimit, result, err
+}
+
+// PurgeIdle deletes buckets every interval until ctx is cancelled. Buckets are deleted once they had time
+// to refill, a deleted bucket is recreated full.
+func (s *RateLimitService) PurgeIdle(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	refill := s.defaultLimit.TimeUntil(0, float64(s.defaultLimit.Burst))
+	refill = max(refill, s.ipLimit.TimeUntil(0, float64(s.ipLimit.Burst)))
+	for _, limit := range s.routeLimits {
+		refill = max(refill, limit.TimeUntil(0, float64(limit.Burst)))
+	}
+
+	for {
+		purged, err := s.rateLimitProvider.Purge(ctx, time.Now().Add(-refill))
+		if err != nil {
+			s.log.Error("failed to purge rate limit buckets", "error", err.Error())
+		} else if purged > 0 {
+			s.log.Debug("rate limit buckets purged", "buckets", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"io"
+	"slices"
+)
+
+// Export writes the subscriptions matching filter to w in format, without pagination. Subscriptions are encoded
+// one at a time as they are read from the storage. CSV and JSON Lines output is written to w in chunks,
+// an XLSX workbook is written once all rows are added. Dates use the MM-YYYY format, so CSV exports can be
+// imported back. If currency is not empty the converted prices are added to every row.
+func (s *SubscriptionService) Export(ctx context.Context, w io.Writer, format string, filter models.SubscriptionsFilter, currency string, filters models.Filters) error {
+	switch format {
+	case models.ExportFormatJSONL:
+		return s.exportJSONL(ctx, w, filter, currency, filters)
+	case models.ExportFormatXLSX:
+		return s.exportXLSX(ctx, w, filter, currency, filters)
+	default:
+		return s.exportCSV(ctx, w, filter, currency, filters)
+	}
+}
+
+func (s *SubscriptionService) exportCSV(ctx context.Context, w io.Writer, filter models.SubscriptionsFilter, currency string, filters models.Filters) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(exportHeader(currency))
+	if err != nil {
+		return err
+	}
+
+	err = s.subscriptionProvider.Export(ctx, filter, currency, filters, func(subscription *models.Subscription) error {
+		values := exportValues(subscription, currency)
+
+		record := make([]string, len(values))
+		for i, value := range values {
+			record[i] = fmt.Sprint(value)
+		}
+
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func (s *SubscriptionService) exportJSONL(ctx context.Context, w io.Writer, filter models.SubscriptionsFilter, currency string, filters models.Filters) error {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+
+	err := s.subscriptionProvider.Export(ctx, filter, currency, filters, func(subscription *models.Subscription) error {
+		return encoder.Encode(subscription)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+func (s *SubscriptionService) exportXLSX(ctx context.Context, w io.Writer, filter models.SubscriptionsFilter, currency string, filters models.Filters) error {
+	const sheet = "Subscriptions"
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	err := f.SetSheetName(f.GetSheetName(0), sheet)
+	if err != nil {
+		return err
+	}
+
+	// The stream writer keeps rows in a temporary file once they take too much memory.
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	header := exportHeader(currency)
+
+	row := make([]any, len(header))
+	for i, column := range header {
+		row[i] = column
+	}
+
+	err = stream.SetRow("A1", row)
+	if err != nil {
+		return err
+	}
+
+	rows := 1
+
+	err = s.subscriptionProvider.Export(ctx, filter, currency, filters, func(subscription *models.Subscription) error {
+		rows++
+
+		cell, err := excelize.CoordinatesToCellName(1, rows)
+		if err != nil {
+			return err
+		}
+
+		return stream.SetRow(cell, exportValues(subscription, currency))
+	})
+	if err != nil {
+		return err
+	}
+
+	err = stream.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteTo(w)
+
+	return err
+}
+
+func exportHeader(currency string) []string {
+	if currency == "" {
+		return models.ExportColumns
+	}
+
+	return slices.Concat(models.ExportColumns, models.ExportConvertedColumns)
+}
+
+// exportValues returns the values of the export columns of the subscription. Missing values are empty strings.
+func exportValues(subscription *models.Subscription, currency string) []any {
+	endDate := ""
+	if subscription.EndDate != nil {
+		endDate = subscription.EndDate.Time().Format("01-2006")
+	}
+
+	values := []any{
+		subscription.ID,
+		subscription.ServiceName,
+		*subscription.Price,
+		subscription.Currency,
+		subscription.BillingPeriod,
+		subscription.UserID.String(),
+		subscription.StartDate.Time().Format("01-2006"),
+		endDate,
+		subscription.Version,
+	}
+
+	if currency != "" {
+		if subscription.Converted != nil {
+			values = append(values, subscription.Converted.Price, subscription.Converted.Currency)
+		} else {
+			values = append(values, "", "")
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"eff-subscriptions/internal/domain/models"
+	"eff-subscriptions/internal/validator"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrInvalidImportFile the imported file cannot be read: it is not a valid CSV file, lacks a required
+// column or has too many rows. Errors of single rows are reported in models.ImportReport instead.
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// Import reads subscriptions from a CSV file with a header and validates every row. Dates use the MM-YYYY format,
+// empty currency and billing_period columns fall back to the defaults. Unless options.DryRun is set the valid rows
+// are stored in a single transaction, rows with errors are skipped and listed in the report.
+func (s *SubscriptionService) Import(ctx context.Context, r io.Reader, options models.ImportOptions) (*models.ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(options.Delimiter)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidImportFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+	}
+
+	columns, err := importColumns(header, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{DryRun: options.DryRun, Errors: []models.ImportRowError{}}
+	var subscriptions []*models.Subscription
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImportFile, err)
+		}
+
+		report.TotalRows++
+		if report.TotalRows > models.MaxImportRows {
+			return nil, fmt.Errorf("%w: file must not contain more than %d rows", ErrInvalidImportFile, models.MaxImportRows)
+		}
+
+		v := validator.New()
+
+		subscription := parseImportRecord(v, record, columns)
+		if models.ValidateSubscription(v, subscription); !v.Valid() {
+			line, _ := reader.FieldPos(0)
+			report.Errors = append(report.Errors, models.ImportRowError{Row: line, Errors: v.Errors})
+			continue
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	report.ValidRows = len(subscriptions)
+	report.InvalidRows = len(report.Errors)
+
+	if options.DryRun || len(subscriptions) == 0 {
+		return report, nil
+	}
+
+	err = s.subscriptionProvider.InsertMany(ctx, subscriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Imported = len(subscriptions)
+
+	s.log.InfoContext(ctx, "subscriptions imported", "count", report.Imported, "skipped", report.InvalidRows)
+
+	return report, nil
+}
+
+// importColumns returns the indexes of the columns of the imported fields in header.
+// Column names are compared case-insensitively.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheet applications often start UTF-8 files with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		indexes[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int, len(models.ImportFields))
+
+	for _, field := range models.ImportFields {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = mapped
+		}
+
+		i, ok := indexes[strings.ToLower(strings.TrimSpace(name))]
+		if ok {
+			columns[field] = i
+			continue
+		}
+
+		if slices.Contains(models.RequiredImportFields, field) {
+			return nil, fmt.Errorf("%w: csv header must contain %s column", ErrInvalidImportFile, name)
+		}
+	}
+
+	return columns, nil
+}
+
+// parseImportRecord converts a CSV record to a subscription. Values which cannot be parsed are reported to v
+// and left empty.
+func parseImportRecord(v *validator.Validator, record []string, columns map[string]int) *models.Subscription {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	subscription := &models.Subscription{
+		ServiceName:   value("service_name"),
+		Currency:      strings.ToUpper(value("currency")),
+		BillingPeriod: strings.ToLower(value("billing_period")),
+	}
+
+	if subscription.Currency == "" {
+		subscription.Currency = models.DefaultCurrency
+	}
+	if subscription.BillingPeriod == "" {
+		subscription.BillingPeriod = models.BillingPeriodMonthly
+	}
+
+	if s := value("price"); s != "" {
+		price, err := strconv.Atoi(s)
+		if err != nil {
+			v.AddError("price", "must be an integer")
+		} else {
+			subscription.Price = &price
+		}
+	}
+
+	if s := value("user_id"); s != "" {
+		userID, err := uuid.Parse(s)
+		if err != nil {
+			v.AddError("user_id", "must be a valid UUID")
+		}
+		subscription.UserID = userID
+	}
+
+	if s := value("start_date"); s != "" {
+		startDate, err := time.Parse("01-2006", s)
+		if err != nil {
+			v.AddError("start_date", "must be a valid date in MM-YYYY format")
+		}
+		subscription.StartDate = models.CustomDate(startDate)
+	}
+
+	if s := value("end_date"); s != "" {
+		endDate, err := time.Parse("01-2006", s)
+		if err != nil {
+			v.AddError("end_date", "must be a valid date in MM-YYYY format")
+		} else {
+			subscription.EndDate = (*models.CustomDate)(&endDate)
+		}
+	}
+
+	return subscription
+}
@@ -1,18 +1,30 @@
 package service
 
 import (
+	"context"
 	"eff-subscriptions/internal/domain/models"
 	"github.com/google/uuid"
 	"log/slog"
+	"time"
 )
 
 type SubscriptionProvider interface {
-	Insert(subscription *models.Subscription) error
-	Get(id int) (*models.Subscription, error)
-	Update(subscription *models.Subscription) error
-	Delete(id int) error
-	GetAll(serviceName string, price int, userID uuid.UUID, startDate models.CustomDate, filters models.Filters) ([]*models.Subscription, models.Metadata, error)
-	GetSubscriptionsSum(userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate) (int, error)
+	Insert(ctx context.Context, subscription *models.Subscription) error
+	InsertMany(ctx context.Context, subscriptions []*models.Subscription) error
+	Get(ctx context.Context, id int) (*models.Subscription, error)
+	Update(ctx context.Context, subscription *models.Subscription) error
+	Delete(ctx context.Context, id int, version int) error
+	Batch(ctx context.Context, operations []*models.BatchOperation, partial bool) error
+	GetTrash(ctx context.Context, filters models.Filters) ([]*models.Subscription, models.Metadata, error)
+	Restore(ctx context.Context, id int) (*models.Subscription, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetAll(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters) ([]*models.Subscription, models.Metadata, error)
+	Export(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters, fn func(*models.Subscription) error) error
+	GetHistory(ctx context.Context, subscriptionID int) ([]*models.SubscriptionHistory, error)
+	GetVersion(ctx context.Context, subscriptionID int, version int) (*models.Subscription, error)
+	InsertPrice(ctx context.Context, price *models.SubscriptionPrice) error
+	GetPrices(ctx context.Context, subscriptionID int) ([]*models.SubscriptionPrice, error)
+	GetSubscriptionsSum(ctx context.Context, userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate, currency string) (*models.SubscriptionsSum, error)
 }
 
 type SubscriptionService struct {
@@ -22,26 +34,74 @@ type SubscriptionService struct {
 
 func NewSubscriptionService(log *slog.Logger, subscriptionProvider SubscriptionProvider) *SubscriptionService {
 	return &SubscriptionService{
+		log:                  log,
 		subscriptionProvider: subscriptionProvider,
 	}
 }
 
-func (s *SubscriptionService) Insert(subscription *models.Subscription) error {
-	return s.subscriptionProvider.Insert(subscription)
+func (s *SubscriptionService) Insert(ctx context.Context, subscription *models.Subscription) error {
+	return s.subscriptionProvider.Insert(ctx, subscription)
 }
-func (s *SubscriptionService) Get(id int) (*models.Subscription, error) {
-	return s.subscriptionProvider.Get(id)
+func (s *SubscriptionService) Get(ctx context.Context, id int) (*models.Subscription, error) {
+	return s.subscriptionProvider.Get(ctx, id)
 }
-func (s *SubscriptionService) Update(subscription *models.Subscription) error {
-	return s.subscriptionProvider.Update(subscription)
+func (s *SubscriptionService) Update(ctx context.Context, subscription *models.Subscription) error {
+	return s.subscriptionProvider.Update(ctx, subscription)
 }
-func (s *SubscriptionService) Delete(id int) error {
-	return s.subscriptionProvider.Delete(id)
+func (s *SubscriptionService) Delete(ctx context.Context, id int, version int) error {
+	return s.subscriptionProvider.Delete(ctx, id, version)
 }
-func (s *SubscriptionService) GetAll(serviceName string, price int, userID uuid.UUID, startDate models.CustomDate, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
-	return s.subscriptionProvider.GetAll(serviceName, price, userID, startDate, filters)
+func (s *SubscriptionService) Batch(ctx context.Context, operations []*models.BatchOperation, partial bool) error {
+	return s.subscriptionProvider.Batch(ctx, operations, partial)
 }
+func (s *SubscriptionService) GetTrash(ctx context.Context, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	return s.subscriptionProvider.GetTrash(ctx, filters)
+}
+func (s *SubscriptionService) Restore(ctx context.Context, id int) (*models.Subscription, error) {
+	return s.subscriptionProvider.Restore(ctx, id)
+}
+func (s *SubscriptionService) GetAll(ctx context.Context, filter models.SubscriptionsFilter, currency string, filters models.Filters) ([]*models.Subscription, models.Metadata, error) {
+	return s.subscriptionProvider.GetAll(ctx, filter, currency, filters)
+}
+
+func (s *SubscriptionService) GetSubscriptionsSum(ctx context.Context, userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate, currency string) (*models.SubscriptionsSum, error) {
+	return s.subscriptionProvider.GetSubscriptionsSum(ctx, userID, serviceName, beginDate, endDate, currency)
+}
+
+func (s *SubscriptionService) GetHistory(ctx context.Context, subscriptionID int) ([]*models.SubscriptionHistory, error) {
+	return s.subscriptionProvider.GetHistory(ctx, subscriptionID)
+}
+
+func (s *SubscriptionService) GetVersion(ctx context.Context, subscriptionID int, version int) (*models.Subscription, error) {
+	return s.subscriptionProvider.GetVersion(ctx, subscriptionID, version)
+}
+
+func (s *SubscriptionService) InsertPrice(ctx context.Context, price *models.SubscriptionPrice) error {
+	return s.subscriptionProvider.InsertPrice(ctx, price)
+}
+
+func (s *SubscriptionService) GetPrices(ctx context.Context, subscriptionID int) ([]*models.SubscriptionPrice, error) {
+	return s.subscriptionProvider.GetPrices(ctx, subscriptionID)
+}
+
+// PurgeTrash permanently deletes subscriptions which have been in the trash longer than retention
+// every interval until ctx is cancelled.
+func (s *SubscriptionService) PurgeTrash(ctx context.Context, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-func (s *SubscriptionService) GetSubscriptionsSum(userID uuid.UUID, serviceName string, beginDate models.CustomDate, endDate models.CustomDate) (int, error) {
-	return s.subscriptionProvider.GetSubscriptionsSum(userID, serviceName, beginDate, endDate)
+	for {
+		purged, err := s.subscriptionProvider.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			s.log.Error("failed to purge trash", "error", err.Error())
+		} else if purged > 0 {
+			s.log.Info("trash purged", "subscriptions", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
@@ -1 +0,0 @@
-TRUNCATE TABLE subscriptions;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS subscriptions_billing_period_check;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS subscriptions_currency_check;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS currency;
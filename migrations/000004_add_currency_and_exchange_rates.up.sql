@@ -0,0 +1,10 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'RUB';
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  base_currency TEXT NOT NULL CHECK (base_currency ~ '^[A-Z]{3}$'),
+  quote_currency TEXT NOT NULL CHECK (quote_currency ~ '^[A-Z]{3}$'),
+  rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+  effective_date DATE NOT NULL,
+  PRIMARY KEY (base_currency, quote_currency, effective_date)
+);
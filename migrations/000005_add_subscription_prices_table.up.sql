@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS subscription_prices (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id BIGINT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+  price INTEGER NOT NULL CHECK (price >= 0),
+  effective_date DATE NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (subscription_id, effective_date)
+);
+
+INSERT INTO subscription_prices(subscription_id, price, effective_date)
+SELECT id, price, start_date FROM subscriptions
+ON CONFLICT DO NOTHING;
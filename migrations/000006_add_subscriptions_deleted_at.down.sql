@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS subscriptions_deleted_at_idx;
+
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN IF EXISTS deleted_at;
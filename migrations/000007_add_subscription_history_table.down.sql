@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS subscriptions_history_trigger ON subscriptions;
+DROP FUNCTION IF EXISTS record_subscription_history();
+DROP FUNCTION IF EXISTS subscription_json(subscriptions);
+
+DROP TABLE IF EXISTS subscription_history;
+DROP FUNCTION IF EXISTS reject_subscription_history_change();
@@ -0,0 +1,98 @@
+CREATE TABLE IF NOT EXISTS subscription_history (
+  id BIGSERIAL PRIMARY KEY,
+  subscription_id BIGINT NOT NULL,
+  version INTEGER NOT NULL,
+  operation TEXT NOT NULL CHECK (operation IN ('snapshot', 'insert', 'update', 'delete', 'restore', 'purge')),
+  changed_by TEXT NOT NULL,
+  changed_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  changed_fields TEXT[] NOT NULL DEFAULT '{}',
+  old_values JSONB NULL,
+  new_values JSONB NULL
+);
+
+CREATE INDEX IF NOT EXISTS subscription_history_subscription_id_idx ON subscription_history (subscription_id, version);
+
+-- subscription_json represents a subscription row the same way the API does.
+CREATE OR REPLACE FUNCTION subscription_json(s subscriptions) RETURNS JSONB AS $$
+BEGIN
+    RETURN jsonb_build_object(
+        'id', s.id,
+        'service_name', s.service_name,
+        'price', s.price,
+        'currency', s.currency,
+        'billing_period', s.billing_period,
+        'user_id', s.user_id,
+        'start_date', to_char(s.start_date, 'MM-YYYY'),
+        'end_date', to_char(s.end_date, 'MM-YYYY'),
+        'deleted_at', s.deleted_at,
+        'version', s.version
+    );
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- record_subscription_history appends a history row for every change of a subscription.
+-- The author of the change is taken from the app.actor setting of the transaction.
+CREATE OR REPLACE FUNCTION record_subscription_history() RETURNS TRIGGER AS $$
+DECLARE
+    actor TEXT := COALESCE(NULLIF(current_setting('app.actor', true), ''), 'system');
+    old_row JSONB;
+    new_row JSONB;
+    op TEXT;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        new_row := subscription_json(NEW);
+        INSERT INTO subscription_history(subscription_id, version, operation, changed_by, new_values)
+        VALUES (NEW.id, NEW.version, 'insert', actor, new_row);
+        RETURN NEW;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO subscription_history(subscription_id, version, operation, changed_by, old_values)
+        VALUES (OLD.id, OLD.version, 'purge', actor, subscription_json(OLD));
+        RETURN OLD;
+    END IF;
+
+    old_row := subscription_json(OLD);
+    new_row := subscription_json(NEW);
+
+    IF old_row = new_row THEN
+        RETURN NEW;
+    END IF;
+
+    op := CASE
+        WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+        WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+        ELSE 'update'
+    END;
+
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, changed_fields, old_values, new_values)
+    VALUES (NEW.id, NEW.version, op, actor,
+            ARRAY(SELECT n.key FROM jsonb_each(new_row) n
+                  WHERE n.key NOT IN ('version', 'deleted_at') AND n.value IS DISTINCT FROM old_row -> n.key
+                  ORDER BY n.key),
+            old_row, new_row);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscriptions_history_trigger ON subscriptions;
+CREATE TRIGGER subscriptions_history_trigger
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION record_subscription_history();
+
+-- History rows are immutable.
+CREATE OR REPLACE FUNCTION reject_subscription_history_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription history is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscription_history_immutable_trigger ON subscription_history;
+CREATE TRIGGER subscription_history_immutable_trigger
+    BEFORE UPDATE OR DELETE ON subscription_history
+    FOR EACH ROW EXECUTE FUNCTION reject_subscription_history_change();
+
+INSERT INTO subscription_history(subscription_id, version, operation, changed_by, changed_at, new_values)
+SELECT s.id, s.version, 'snapshot', 'system', s.created_at, subscription_json(s)
+FROM subscriptions s;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  prefix TEXT NOT NULL,
+  key_hash BYTEA NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL,
+  created_at TIMESTAMP(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP(0) WITH TIME ZONE NULL,
+  last_used_at TIMESTAMP(0) WITH TIME ZONE NULL,
+  revoked_at TIMESTAMP(0) WITH TIME ZONE NULL
+);
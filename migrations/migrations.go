@@ -0,0 +1,25 @@
+// Package migrations embeds versioned schema migrations and seed data into the binary.
+// Postgres migrations live in the root directory, SQLite ones in the sqlite directory.
+package migrations
+
+import "embed"
+
+// Schema migrations named <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed *.sql
+var Schema embed.FS
+
+// Seeds demo data which is never applied by schema migrations.
+//
+//go:embed seeds/*.sql
+var Seeds embed.FS
+
+// SQLite schema migrations of the SQLite storage.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
+
+// SQLiteSeeds demo data for the SQLite storage.
+//
+//go:embed sqlite/seeds/*.sql
+var SQLiteSeeds embed.FS
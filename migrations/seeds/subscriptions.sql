@@ -0,0 +1,20 @@
+-- Demo subscriptions, inserted only into an empty table.
+INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date)
+SELECT v.service_name, v.price, v.user_id::uuid, v.start_date::date, v.end_date::date
+FROM (VALUES
+    ('test0', 100, '8f7413b3-6585-4eb0-87dd-a961d13a57ac', '2025-01-01', null),
+    ('test1', 200, '3a648c12-e8f2-4655-aa38-596dfa896fe2', '2025-02-01', null),
+    ('test2', 300, 'cc73a54c-021b-4585-9ba4-f0db6aa14f25', '2025-03-01', '2025-04-01'),
+    ('test0', 400, '8f7413b3-6585-4eb0-87dd-a961d13a57ac', '2025-04-01', null),
+    ('test2', 500, '8f7413b3-6585-4eb0-87dd-a961d13a57ac', '2025-05-01', null),
+    ('test2', 600, '8f7413b3-6585-4eb0-87dd-a961d13a57ac', '2025-06-01', null),
+    ('test0', 700, '3a648c12-e8f2-4655-aa38-596dfa896fe2', '2025-07-01', '2025-08-01'),
+    ('test1', 800, 'cc73a54c-021b-4585-9ba4-f0db6aa14f25', '2025-08-01', null),
+    ('test1', 900, '3a648c12-e8f2-4655-aa38-596dfa896fe2', '2025-09-01', '2025-10-01'),
+    ('test1', 999, 'cc73a54c-021b-4585-9ba4-f0db6aa14f25', '2025-10-01', null)
+) AS v(service_name, price, user_id, start_date, end_date)
+WHERE NOT EXISTS (SELECT 1 FROM subscriptions);
+
+INSERT INTO subscription_prices(subscription_id, price, effective_date)
+SELECT id, price, start_date FROM subscriptions
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS subscription_history_immutable_delete;
+DROP TRIGGER IF EXISTS subscription_history_immutable_update;
+DROP TRIGGER IF EXISTS subscriptions_history_delete;
+DROP TRIGGER IF EXISTS subscriptions_history_update;
+DROP TRIGGER IF EXISTS subscriptions_history_insert;
+
+DROP TABLE IF EXISTS subscription_history;
+DROP TABLE IF EXISTS subscription_prices;
+DROP TABLE IF EXISTS exchange_rates;
+DROP TABLE IF EXISTS subscriptions;
@@ -0,0 +1,109 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  service_name TEXT NOT NULL,
+  price INTEGER NOT NULL,
+  currency TEXT NOT NULL DEFAULT 'RUB' CHECK (length(currency) = 3 AND currency = upper(currency)),
+  billing_period TEXT NOT NULL DEFAULT 'monthly' CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+  user_id TEXT NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP NULL DEFAULT NULL,
+  version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS subscriptions_deleted_at_idx ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+  base_currency TEXT NOT NULL,
+  quote_currency TEXT NOT NULL,
+  rate REAL NOT NULL CHECK (rate > 0),
+  effective_date DATE NOT NULL,
+  PRIMARY KEY (base_currency, quote_currency, effective_date)
+);
+
+CREATE TABLE IF NOT EXISTS subscription_prices (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+  price INTEGER NOT NULL CHECK (price >= 0),
+  effective_date DATE NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (subscription_id, effective_date)
+);
+
+CREATE TABLE IF NOT EXISTS subscription_history (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  subscription_id INTEGER NOT NULL,
+  version INTEGER NOT NULL,
+  operation TEXT NOT NULL CHECK (operation IN ('snapshot', 'insert', 'update', 'delete', 'restore', 'purge')),
+  changed_by TEXT NOT NULL,
+  changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  changed_fields TEXT NOT NULL DEFAULT '[]',
+  old_values TEXT NULL,
+  new_values TEXT NULL
+);
+
+CREATE INDEX IF NOT EXISTS subscription_history_subscription_id_idx ON subscription_history (subscription_id, version);
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_insert AFTER INSERT ON subscriptions
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, new_values)
+    VALUES (NEW.id, NEW.version, 'insert', 'system',
+            json_object('id', NEW.id, 'service_name', NEW.service_name, 'price', NEW.price, 'currency', NEW.currency,
+                        'billing_period', NEW.billing_period, 'user_id', NEW.user_id,
+                        'start_date', strftime('%m-%Y', NEW.start_date), 'end_date', strftime('%m-%Y', NEW.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', NEW.deleted_at), 'version', NEW.version));
+END;
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_update AFTER UPDATE ON subscriptions
+WHEN OLD.service_name IS NOT NEW.service_name OR OLD.price IS NOT NEW.price OR OLD.currency IS NOT NEW.currency
+    OR OLD.billing_period IS NOT NEW.billing_period OR OLD.user_id IS NOT NEW.user_id
+    OR OLD.start_date IS NOT NEW.start_date OR OLD.end_date IS NOT NEW.end_date
+    OR OLD.deleted_at IS NOT NEW.deleted_at OR OLD.version IS NOT NEW.version
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, changed_fields, old_values, new_values)
+    VALUES (NEW.id, NEW.version,
+            CASE
+                WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+                WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+                ELSE 'update'
+            END,
+            'system',
+            (SELECT json_group_array(name) FROM (
+                SELECT 'billing_period' AS name WHERE OLD.billing_period IS NOT NEW.billing_period
+                UNION ALL SELECT 'currency' WHERE OLD.currency IS NOT NEW.currency
+                UNION ALL SELECT 'end_date' WHERE OLD.end_date IS NOT NEW.end_date
+                UNION ALL SELECT 'price' WHERE OLD.price IS NOT NEW.price
+                UNION ALL SELECT 'service_name' WHERE OLD.service_name IS NOT NEW.service_name
+                UNION ALL SELECT 'start_date' WHERE OLD.start_date IS NOT NEW.start_date
+                UNION ALL SELECT 'user_id' WHERE OLD.user_id IS NOT NEW.user_id)),
+            json_object('id', OLD.id, 'service_name', OLD.service_name, 'price', OLD.price, 'currency', OLD.currency,
+                        'billing_period', OLD.billing_period, 'user_id', OLD.user_id,
+                        'start_date', strftime('%m-%Y', OLD.start_date), 'end_date', strftime('%m-%Y', OLD.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', OLD.deleted_at), 'version', OLD.version),
+            json_object('id', NEW.id, 'service_name', NEW.service_name, 'price', NEW.price, 'currency', NEW.currency,
+                        'billing_period', NEW.billing_period, 'user_id', NEW.user_id,
+                        'start_date', strftime('%m-%Y', NEW.start_date), 'end_date', strftime('%m-%Y', NEW.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', NEW.deleted_at), 'version', NEW.version));
+END;
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_delete AFTER DELETE ON subscriptions
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, old_values)
+    VALUES (OLD.id, OLD.version, 'purge', 'system',
+            json_object('id', OLD.id, 'service_name', OLD.service_name, 'price', OLD.price, 'currency', OLD.currency,
+                        'billing_period', OLD.billing_period, 'user_id', OLD.user_id,
+                        'start_date', strftime('%m-%Y', OLD.start_date), 'end_date', strftime('%m-%Y', OLD.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', OLD.deleted_at), 'version', OLD.version));
+END;
+
+-- History rows are immutable.
+CREATE TRIGGER IF NOT EXISTS subscription_history_immutable_update BEFORE UPDATE ON subscription_history
+BEGIN
+    SELECT RAISE(ABORT, 'subscription history is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS subscription_history_immutable_delete BEFORE DELETE ON subscription_history
+BEGIN
+    SELECT RAISE(ABORT, 'subscription history is immutable');
+END;
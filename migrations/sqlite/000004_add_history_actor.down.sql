@@ -0,0 +1,57 @@
+DROP TRIGGER IF EXISTS subscriptions_history_delete;
+DROP TRIGGER IF EXISTS subscriptions_history_update;
+DROP TRIGGER IF EXISTS subscriptions_history_insert;
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_insert AFTER INSERT ON subscriptions
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, new_values)
+    VALUES (NEW.id, NEW.version, 'insert', 'system',
+            json_object('id', NEW.id, 'service_name', NEW.service_name, 'price', NEW.price, 'currency', NEW.currency,
+                        'billing_period', NEW.billing_period, 'user_id', NEW.user_id,
+                        'start_date', strftime('%m-%Y', NEW.start_date), 'end_date', strftime('%m-%Y', NEW.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', NEW.deleted_at), 'version', NEW.version));
+END;
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_update AFTER UPDATE ON subscriptions
+WHEN OLD.service_name IS NOT NEW.service_name OR OLD.price IS NOT NEW.price OR OLD.currency IS NOT NEW.currency
+    OR OLD.billing_period IS NOT NEW.billing_period OR OLD.user_id IS NOT NEW.user_id
+    OR OLD.start_date IS NOT NEW.start_date OR OLD.end_date IS NOT NEW.end_date
+    OR OLD.deleted_at IS NOT NEW.deleted_at OR OLD.version IS NOT NEW.version
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, changed_fields, old_values, new_values)
+    VALUES (NEW.id, NEW.version,
+            CASE
+                WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+                WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+                ELSE 'update'
+            END,
+            'system',
+            (SELECT json_group_array(name) FROM (
+                SELECT 'billing_period' AS name WHERE OLD.billing_period IS NOT NEW.billing_period
+                UNION ALL SELECT 'currency' WHERE OLD.currency IS NOT NEW.currency
+                UNION ALL SELECT 'end_date' WHERE OLD.end_date IS NOT NEW.end_date
+                UNION ALL SELECT 'price' WHERE OLD.price IS NOT NEW.price
+                UNION ALL SELECT 'service_name' WHERE OLD.service_name IS NOT NEW.service_name
+                UNION ALL SELECT 'start_date' WHERE OLD.start_date IS NOT NEW.start_date
+                UNION ALL SELECT 'user_id' WHERE OLD.user_id IS NOT NEW.user_id)),
+            json_object('id', OLD.id, 'service_name', OLD.service_name, 'price', OLD.price, 'currency', OLD.currency,
+                        'billing_period', OLD.billing_period, 'user_id', OLD.user_id,
+                        'start_date', strftime('%m-%Y', OLD.start_date), 'end_date', strftime('%m-%Y', OLD.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', OLD.deleted_at), 'version', OLD.version),
+            json_object('id', NEW.id, 'service_name', NEW.service_name, 'price', NEW.price, 'currency', NEW.currency,
+                        'billing_period', NEW.billing_period, 'user_id', NEW.user_id,
+                        'start_date', strftime('%m-%Y', NEW.start_date), 'end_date', strftime('%m-%Y', NEW.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', NEW.deleted_at), 'version', NEW.version));
+END;
+
+CREATE TRIGGER IF NOT EXISTS subscriptions_history_delete AFTER DELETE ON subscriptions
+BEGIN
+    INSERT INTO subscription_history(subscription_id, version, operation, changed_by, old_values)
+    VALUES (OLD.id, OLD.version, 'purge', 'system',
+            json_object('id', OLD.id, 'service_name', OLD.service_name, 'price', OLD.price, 'currency', OLD.currency,
+                        'billing_period', OLD.billing_period, 'user_id', OLD.user_id,
+                        'start_date', strftime('%m-%Y', OLD.start_date), 'end_date', strftime('%m-%Y', OLD.end_date),
+                        'deleted_at', strftime('%Y-%m-%dT%H:%M:%SZ', OLD.deleted_at), 'version', OLD.version));
+END;
+
+DROP TABLE IF EXISTS history_actor;